@@ -0,0 +1,232 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var (
+	_ json.Marshaler   = AdjacentDecodable[any, any, TypeData, RegistryDecider[any, any]]{}
+	_ json.Unmarshaler = &AdjacentDecodable[any, any, TypeData, RegistryDecider[any, any]]{}
+
+	_ msgpack.Marshaler     = AdjacentDecodable[any, any, TypeData, RegistryDecider[any, any]]{}
+	_ msgpack.Unmarshaler   = &AdjacentDecodable[any, any, TypeData, RegistryDecider[any, any]]{}
+	_ msgpack.CustomEncoder = AdjacentDecodable[any, any, TypeData, RegistryDecider[any, any]]{}
+	_ msgpack.CustomDecoder = &AdjacentDecodable[any, any, TypeData, RegistryDecider[any, any]]{}
+)
+
+// Envelope is an interface for types that name the keys of an adjacently tagged object,
+// where the discriminator and the content are siblings: {"type": "dog", "data": {...}}.
+type Envelope interface {
+	// TagKey returns the key holding the discriminator.
+	TagKey() string
+	// ContentKey returns the key holding the value.
+	ContentKey() string
+	~struct{}
+}
+
+// TypeData is the envelope {"type": ..., "data": ...}.
+type TypeData struct{}
+
+// TagKey returns "type".
+func (TypeData) TagKey() string { return "type" }
+
+// ContentKey returns "data".
+func (TypeData) ContentKey() string { return "data" }
+
+// AdjacentDecodable is a generic wrapper for polymorphic (de)serialization of adjacently tagged objects.
+// I is the interface type, X is the discriminator type, E is the envelope and D is the decider.
+// The discriminator is decoded from the tag key of E and the value from the content key of E.
+// Marshaling requires D to implement Discriminator.
+type AdjacentDecodable[I any, X any, E Envelope, D Decider[I, X]] struct {
+	I I // The decoded value implementing I
+}
+
+// RAdjacentDecodable is a type alias for AdjacentDecodable using RegistryDecider.
+type RAdjacentDecodable[I any, X comparable, E Envelope] = AdjacentDecodable[I, X, E, RegistryDecider[I, X]]
+
+// MarshalJSON marshals the contained value into the envelope E using JSON.
+func (d AdjacentDecodable[I, X, E, D]) MarshalJSON() ([]byte, error) {
+	if any(d.I) == nil {
+		return []byte("null"), nil
+	}
+
+	x, err := mustDiscriminate[I, X, D](d.I)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(d.I)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope E
+	tagKey, err := json.Marshal(envelope.TagKey())
+	if err != nil {
+		return nil, err
+	}
+	contentKey, err := json.Marshal(envelope.ContentKey())
+	if err != nil {
+		return nil, err
+	}
+	return encodeJSONObject([]jsonField{{key: tagKey, value: tag}, {key: contentKey, value: content}}), nil
+}
+
+// UnmarshalJSON does unmarshal the envelope E in data into the contained value using JSON.
+// A missing content key leaves the value as returned by the decider.
+func (d *AdjacentDecodable[I, X, E, D]) UnmarshalJSON(data []byte) error {
+	fields, ok := splitJSONObject(data)
+	if !ok {
+		if isJSONNull(data) {
+			var i I
+			d.I = i
+			return nil
+		}
+		return discriminatorError[I, X](codecJSON, jsonObjectError(data))
+	}
+
+	var envelope E
+	tag, ok := findJSONField(fields, envelope.TagKey())
+	if !ok {
+		return discriminatorError[I, X](codecJSON, &MissingFieldError{Interface: reflect.TypeFor[I](), Selector: reflect.TypeFor[E](), Field: envelope.TagKey()})
+	}
+
+	x := new(X)
+	err := json.Unmarshal(tag, x)
+	if err != nil {
+		return discriminatorError[I, X](codecJSON, err)
+	}
+
+	var decider D
+	d.I, err = decider.Decide(*x)
+	if err != nil {
+		return decideError[I](codecJSON, *x, err)
+	}
+
+	content, ok := findJSONField(fields, envelope.ContentKey())
+	if !ok {
+		return nil
+	}
+	return payloadError(codecJSON, *x, d.I, json.Unmarshal(content, d.I))
+}
+
+// MarshalMsgpack marshals the contained value into the envelope E using msgpack.
+func (d AdjacentDecodable[I, X, E, D]) MarshalMsgpack() ([]byte, error) {
+	if any(d.I) == nil {
+		return msgpack.Marshal(nil)
+	}
+
+	x, err := mustDiscriminate[I, X, D](d.I)
+	if err != nil {
+		return nil, err
+	}
+
+	tag, err := msgpack.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	content, err := msgpack.Marshal(d.I)
+	if err != nil {
+		return nil, err
+	}
+
+	var envelope E
+	tagKey, err := msgpack.Marshal(envelope.TagKey())
+	if err != nil {
+		return nil, err
+	}
+	contentKey, err := msgpack.Marshal(envelope.ContentKey())
+	if err != nil {
+		return nil, err
+	}
+	return encodeMsgpackMap([]msgpackField{{key: tagKey, value: tag}, {key: contentKey, value: content}})
+}
+
+// EncodeMsgpack encodes the contained value into the envelope E to the msgpack encoder.
+func (d AdjacentDecodable[I, X, E, D]) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if any(d.I) == nil {
+		return enc.EncodeNil()
+	}
+
+	x, err := mustDiscriminate[I, X, D](d.I)
+	if err != nil {
+		return err
+	}
+
+	var envelope E
+	if err := enc.EncodeMapLen(2); err != nil {
+		return err
+	}
+	return enc.EncodeMulti(envelope.TagKey(), x, envelope.ContentKey(), d.I)
+}
+
+// DecodeMsgpack decodes the envelope E from the msgpack decoder into the contained value.
+func (d *AdjacentDecodable[I, X, E, D]) DecodeMsgpack(dec *msgpack.Decoder) error {
+	data, err := dec.DecodeRaw()
+	if err != nil {
+		return discriminatorError[I, X](codecMsgpack, err)
+	}
+	return d.UnmarshalMsgpack(data)
+}
+
+// UnmarshalMsgpack does unmarshal the envelope E in data into the contained value using msgpack.
+// A missing content key leaves the value as returned by the decider.
+func (d *AdjacentDecodable[I, X, E, D]) UnmarshalMsgpack(data []byte) error {
+	fields, ok := splitMsgpackMap(data)
+	if !ok {
+		if isMsgpackNil(data) {
+			var i I
+			d.I = i
+			return nil
+		}
+		return discriminatorError[I, X](codecMsgpack, msgpackMapError(data))
+	}
+
+	var envelope E
+	tag, ok := findMsgpackField(fields, envelope.TagKey())
+	if !ok {
+		return discriminatorError[I, X](codecMsgpack, &MissingFieldError{Interface: reflect.TypeFor[I](), Selector: reflect.TypeFor[E](), Field: envelope.TagKey()})
+	}
+
+	x := new(X)
+	err := msgpack.Unmarshal(tag, x)
+	if err != nil {
+		return discriminatorError[I, X](codecMsgpack, err)
+	}
+
+	var decider D
+	d.I, err = decider.Decide(*x)
+	if err != nil {
+		return decideError[I](codecMsgpack, *x, err)
+	}
+
+	content, ok := findMsgpackField(fields, envelope.ContentKey())
+	if !ok {
+		return nil
+	}
+	return payloadError(codecMsgpack, *x, d.I, msgpack.Unmarshal(content, d.I))
+}
+
+// mustDiscriminate returns the discriminator for i or an error if neither the decider D
+// nor i itself, by implementing Tagged, can provide one.
+func mustDiscriminate[I any, X any, D Decider[I, X]](i I) (X, error) {
+	x, ok := discriminate[I, X, D](i)
+	if ok {
+		return x, nil
+	}
+	if tagged, ok := any(i).(Tagged[X]); ok {
+		return tagged.Tag(), nil
+	}
+	return x, &NoDiscriminatorError{Interface: reflect.TypeFor[I](), Discriminator: reflect.TypeFor[X](), Type: reflect.TypeOf(i)}
+}
@@ -0,0 +1,178 @@
+package ijson_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/Nikkolix/ijson"
+)
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	Radius float64 `json:"radius" msgpack:"radius"`
+}
+
+func (c *Circle) Area() float64 { return 3 * c.Radius * c.Radius }
+
+type Square struct {
+	Side float64 `json:"side" msgpack:"side"`
+}
+
+func (s *Square) Area() float64 { return s.Side * s.Side }
+
+type KindSpec struct{}
+
+func (KindSpec) TagKey() string     { return "kind" }
+func (KindSpec) ContentKey() string { return "spec" }
+
+func registerShapes(t *testing.T) {
+	t.Helper()
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterT[Circle, Shape]("circle"))
+	require.NoError(t, ijson.RegisterT[Square, Shape]("square"))
+}
+
+func TestAdjacentDecodable_UnmarshalJSON(t *testing.T) {
+	registerShapes(t)
+
+	var d ijson.RAdjacentDecodable[Shape, string, ijson.TypeData]
+	err := json.Unmarshal([]byte(`{"data":{"radius":2},"type":"circle"}`), &d)
+	require.NoError(t, err)
+	assert.Equal(t, &Circle{Radius: 2}, d.I)
+
+	var k ijson.RAdjacentDecodable[Shape, string, KindSpec]
+	err = json.Unmarshal([]byte(`{"kind":"square","spec":{"side":3}}`), &k)
+	require.NoError(t, err)
+	assert.Equal(t, &Square{Side: 3}, k.I)
+}
+
+func TestAdjacentDecodable_MarshalJSON_RoundTrip(t *testing.T) {
+	registerShapes(t)
+
+	in := []ijson.RAdjacentDecodable[Shape, string, KindSpec]{{I: &Circle{Radius: 1}}, {I: &Square{Side: 2}}, {}}
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"kind":"circle","spec":{"radius":1}},{"kind":"square","spec":{"side":2}},null]`, string(data))
+
+	var out []ijson.RAdjacentDecodable[Shape, string, KindSpec]
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+}
+
+func TestAdjacentDecodable_UnmarshalJSON_MissingContent(t *testing.T) {
+	registerShapes(t)
+
+	var d ijson.RAdjacentDecodable[Shape, string, ijson.TypeData]
+	require.NoError(t, d.UnmarshalJSON([]byte(`{"type":"circle"}`)))
+	assert.Equal(t, &Circle{}, d.I)
+}
+
+func TestAdjacentDecodable_UnmarshalJSON_Errors(t *testing.T) {
+	registerShapes(t)
+
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{name: "missing tag", data: `{"data":{}}`, err: "discriminator field type not found"},
+		{name: "invalid tag", data: `{"type":1}`, err: "json: cannot unmarshal number into Go value of type string"},
+		{name: "unknown tag", data: `{"type":"hexagon"}`, err: "no factory found in registry[I: ijson_test.Shape, X: string] and X value hexagon"},
+		{name: "invalid content", data: `{"type":"circle","data":[]}`, err: "decode *ijson_test.Circle for X value circle: json: cannot unmarshal array into Go value of type ijson_test.Circle"},
+		{name: "not an object", data: `[]`, err: "json: cannot unmarshal array into Go value of type map[string]"},
+		{name: "invalid json", data: `{"type":}`, err: "invalid character '}' looking for beginning of value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d ijson.RAdjacentDecodable[Shape, string, ijson.TypeData]
+			err := d.UnmarshalJSON([]byte(tt.data))
+			require.Error(t, err)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestAdjacentDecodable_Marshal_Unregistered(t *testing.T) {
+	ijson.ResetRegistries()
+
+	d := ijson.RAdjacentDecodable[Shape, string, ijson.TypeData]{I: &Circle{}}
+
+	_, err := d.MarshalJSON()
+	require.Error(t, err)
+	assert.Equal(t, "no discriminator found for type *ijson_test.Circle", err.Error())
+	assert.ErrorIs(t, err, ijson.ErrNoDiscriminator)
+	var noDiscriminatorErr *ijson.NoDiscriminatorError
+	require.ErrorAs(t, err, &noDiscriminatorErr)
+	assert.Equal(t, reflect.TypeFor[Shape](), noDiscriminatorErr.Interface)
+	assert.Equal(t, reflect.TypeFor[string](), noDiscriminatorErr.Discriminator)
+	assert.Equal(t, reflect.TypeFor[*Circle](), noDiscriminatorErr.Type)
+
+	_, err = d.MarshalMsgpack()
+	require.Error(t, err)
+	assert.Equal(t, "no discriminator found for type *ijson_test.Circle", err.Error())
+}
+
+func TestAdjacentDecodable_Msgpack_RoundTrip(t *testing.T) {
+	registerShapes(t)
+
+	type Drawing struct {
+		Shapes []ijson.RAdjacentDecodable[Shape, string, KindSpec] `msgpack:"shapes"`
+	}
+
+	in := Drawing{Shapes: []ijson.RAdjacentDecodable[Shape, string, KindSpec]{{I: &Circle{Radius: 1}}, {I: &Square{Side: 2}}, {}}}
+	data, err := msgpack.Marshal(in)
+	require.NoError(t, err)
+
+	var raw map[string][]map[string]any
+	require.NoError(t, msgpack.Unmarshal(data, &raw))
+	assert.Equal(t, "circle", raw["shapes"][0]["kind"])
+
+	var out Drawing
+	require.NoError(t, msgpack.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+}
+
+func TestAdjacentDecodable_UnmarshalMsgpack_Errors(t *testing.T) {
+	registerShapes(t)
+
+	encode := func(v any) []byte {
+		data, err := msgpack.Marshal(v)
+		require.NoError(t, err)
+		return data
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{name: "missing tag", data: encode(map[string]any{"data": nil}), err: "msgpack: decode discriminator string: discriminator field type not found"},
+		{name: "invalid tag", data: encode(map[string]any{"type": []int{}}), err: "msgpack: decode discriminator string: msgpack: invalid code=90 decoding string/bytes length"},
+		{name: "unknown tag", data: encode(map[string]any{"type": "hexagon"}), err: "msgpack: decide ijson_test.Shape: no factory found in registry[I: ijson_test.Shape, X: string] and X value hexagon"},
+		{name: "invalid content", data: encode(map[string]any{"type": "circle", "data": "x"}), err: "msgpack: decode *ijson_test.Circle for X value circle: msgpack: unexpected code=a1 decoding map length"},
+		{name: "not a map", data: encode([]int{}), err: "msgpack: decode discriminator string: msgpack: unexpected code=90 decoding map length"},
+		{name: "invalid msgpack", data: []byte{0xc1}, err: "msgpack: decode discriminator string: msgpack: unexpected code=c1 decoding map length"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d ijson.RAdjacentDecodable[Shape, string, ijson.TypeData]
+			err := d.UnmarshalMsgpack(tt.data)
+			require.Error(t, err)
+			assert.Equal(t, tt.err, err.Error())
+		})
+	}
+
+	var d ijson.RAdjacentDecodable[Shape, string, ijson.TypeData]
+	require.NoError(t, d.UnmarshalMsgpack(encode(map[string]any{"type": "square"})))
+	assert.Equal(t, &Square{}, d.I)
+}
@@ -0,0 +1,206 @@
+package ijson_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/Nikkolix/ijson"
+)
+
+type BenchEvent struct {
+	Type    string            `json:"type"`
+	ID      int               `json:"id"`
+	Source  string            `json:"source"`
+	Tags    []string          `json:"tags"`
+	Payload map[string]string `json:"payload"`
+	Items   []BenchItem       `json:"items"`
+}
+
+type BenchItem struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+func (b *BenchEvent) GetType() string {
+	return b.Type
+}
+
+func benchPayload() []byte {
+	var sb strings.Builder
+	sb.WriteString(`{"id":42,"source":"bench","tags":["a","b","c"],"payload":{`)
+	for i := range 32 {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `"key%d":"value%d"`, i, i)
+	}
+	sb.WriteString(`},"items":[`)
+	for i := range 64 {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		fmt.Fprintf(&sb, `{"name":"item%d","price":%d.5}`, i, i)
+	}
+	sb.WriteString(`],"type":"event"}`)
+	return []byte(sb.String())
+}
+
+// twoPassUnmarshalJSON decodes the payload twice, once for the discriminator and once for the value.
+func twoPassUnmarshalJSON(data []byte) (UnmarshalTestInterface, error) {
+	var x UnmarshalDiscriminator
+	if err := json.Unmarshal(data, &x); err != nil {
+		return nil, err
+	}
+
+	var decider ijson.RegistryDecider[UnmarshalTestInterface, UnmarshalDiscriminator]
+	i, err := decider.Decide(x)
+	if err != nil {
+		return nil, err
+	}
+	return i, json.Unmarshal(data, i)
+}
+
+func BenchmarkDecodable_UnmarshalJSON(b *testing.B) {
+	ijson.ResetRegistries()
+	if err := ijson.RegisterT[BenchEvent, UnmarshalTestInterface](UnmarshalDiscriminator{Type: "event"}); err != nil {
+		b.Fatal(err)
+	}
+	data := benchPayload()
+
+	b.Run("Decodable", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for b.Loop() {
+			var d ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]
+			if err := d.UnmarshalJSON(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("TwoPass", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for b.Loop() {
+			if _, err := twoPassUnmarshalJSON(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+type BenchMsgpackEvent struct {
+	Type    string            `msgpack:"type"`
+	ID      int               `msgpack:"id"`
+	Tags    []string          `msgpack:"tags"`
+	Payload map[string]string `msgpack:"payload"`
+}
+
+func (b *BenchMsgpackEvent) GetType() string {
+	return b.Type
+}
+
+// twoPassUnmarshalMsgpack decodes the payload twice, once for the discriminator and once for the value.
+func twoPassUnmarshalMsgpack(data []byte) (UnmarshalTestInterface, error) {
+	var x UnmarshalDiscriminator
+	if err := msgpack.Unmarshal(data, &x); err != nil {
+		return nil, err
+	}
+
+	var decider ijson.RegistryDecider[UnmarshalTestInterface, UnmarshalDiscriminator]
+	i, err := decider.Decide(x)
+	if err != nil {
+		return nil, err
+	}
+	return i, msgpack.Unmarshal(data, i)
+}
+
+func BenchmarkDecodable_UnmarshalMsgpack(b *testing.B) {
+	ijson.ResetRegistries()
+	if err := ijson.RegisterT[BenchMsgpackEvent, UnmarshalTestInterface](UnmarshalDiscriminator{Type: "event"}); err != nil {
+		b.Fatal(err)
+	}
+
+	payload := make(map[string]string, 64)
+	for i := range 64 {
+		payload[fmt.Sprintf("key%d", i)] = fmt.Sprintf("value%d", i)
+	}
+	data, err := msgpack.Marshal(BenchMsgpackEvent{Type: "event", ID: 42, Tags: []string{"a", "b", "c"}, Payload: payload})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("SinglePass", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for b.Loop() {
+			var d ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]
+			if err := d.UnmarshalMsgpack(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("TwoPass", func(b *testing.B) {
+		b.ReportAllocs()
+		b.SetBytes(int64(len(data)))
+		for b.Loop() {
+			if _, err := twoPassUnmarshalMsgpack(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+var benchRegistry = ijson.NewRegistry()
+
+type BenchScope struct{}
+
+func (BenchScope) Registry() *ijson.Registry { return benchRegistry }
+
+func BenchmarkScopedDecider_Decide_Parallel(b *testing.B) {
+	benchRegistry.Reset()
+	x := UnmarshalDiscriminator{Type: "event"}
+	if err := ijson.RegisterTIn[BenchEvent, UnmarshalTestInterface](benchRegistry, x); err != nil {
+		b.Fatal(err)
+	}
+	if err := ijson.RegisterFIn[UnmarshalTestInterface, TestFSelector](benchRegistry, "event", func() UnmarshalTestInterface { return &BenchEvent{} }); err != nil {
+		b.Fatal(err)
+	}
+
+	decide := func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			var decider ijson.ScopedDecider[UnmarshalTestInterface, UnmarshalDiscriminator, BenchScope]
+			for pb.Next() {
+				if _, err := decider.Decide(x); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	}
+	decideF := func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			var decider ijson.ScopedFDecider[UnmarshalTestInterface, TestFSelector, string, BenchScope]
+			v := ijson.FValue[TestFSelector, string]{X: "event", Found: true}
+			for pb.Next() {
+				if _, err := decider.Decide(v); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	}
+
+	b.Run("Locked", decide)
+	b.Run("LockedF", decideF)
+	benchRegistry.Freeze()
+	b.Run("Frozen", decide)
+	b.Run("FrozenF", decideF)
+}
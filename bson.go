@@ -0,0 +1,135 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// lookupBSON returns the value at path in the BSON document data.
+// It reports false if a key is missing or a value on the path is not an embedded document.
+// An error is returned only if data is not a valid document.
+func lookupBSON(data []byte, path []string) (bson.RawValue, bool, error) {
+	if err := bson.Raw(data).Validate(); err != nil {
+		return bson.RawValue{}, false, err
+	}
+
+	value := bson.RawValue{Type: bson.TypeEmbeddedDocument, Value: data}
+	for _, key := range path {
+		if value.Type != bson.TypeEmbeddedDocument {
+			return bson.RawValue{}, false, nil
+		}
+
+		elements, err := value.Document().Elements()
+		if err != nil {
+			return bson.RawValue{}, false, err
+		}
+		found := false
+		for _, e := range elements {
+			if e.Key() == key {
+				value, found = e.Value(), true
+			}
+		}
+		if !found {
+			return bson.RawValue{}, false, nil
+		}
+	}
+	return value, true, nil
+}
+
+// lookupBSONPaths returns the values at paths in the BSON document data, reading each document once.
+// The value of a path has type 0 if a key is missing or a value on the path is not an embedded document.
+// An error is returned only if data is not a valid document.
+func lookupBSONPaths(data []byte, paths [][]string) ([]bson.RawValue, error) {
+	if err := bson.Raw(data).Validate(); err != nil {
+		return nil, err
+	}
+
+	values := make([]bson.RawValue, len(paths))
+	var err error
+	lookupPaths(bson.RawValue{Type: bson.TypeEmbeddedDocument, Value: data}, paths, values,
+		func(value bson.RawValue, _ []string) (func(string) (bson.RawValue, bool), bool) {
+			if value.Type != bson.TypeEmbeddedDocument || err != nil {
+				return nil, false
+			}
+			elements, elementsErr := value.Document().Elements()
+			if elementsErr != nil {
+				err = elementsErr
+				return nil, false
+			}
+			return func(key string) (bson.RawValue, bool) {
+				var value bson.RawValue
+				found := false
+				for _, e := range elements {
+					if e.Key() == key {
+						value, found = e.Value(), true
+					}
+				}
+				return value, found
+			}, true
+		})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// nestBSON wraps the BSON value into documents along path.
+func nestBSON(value bson.RawValue, path []string) ([]byte, error) {
+	for i := len(path) - 1; i > 0; i-- {
+		data, err := bson.Marshal(bson.D{{Key: path[i], Value: value}})
+		if err != nil {
+			return nil, err
+		}
+		value = bson.RawValue{Type: bson.TypeEmbeddedDocument, Value: data}
+	}
+	return bson.Marshal(bson.D{{Key: path[0], Value: value}})
+}
+
+// mergeBSON merges the elements of the BSON document tag into the BSON document value.
+// Elements of value that also exist in tag are replaced in place, or merged if both are documents,
+// all other elements of tag are prepended.
+// If either input is not a document, value is returned unchanged.
+func mergeBSON(tag, value []byte) ([]byte, error) {
+	tagElements, err := bson.Raw(tag).Elements()
+	if err != nil {
+		return value, nil
+	}
+	valueElements, err := bson.Raw(value).Elements()
+	if err != nil {
+		return value, nil
+	}
+
+	merged := make(bson.D, 0, len(tagElements)+len(valueElements))
+	used := make([]bool, len(tagElements))
+	for _, ve := range valueElements {
+		e := bson.E{Key: ve.Key(), Value: ve.Value()}
+		for i, te := range tagElements {
+			if te.Key() == e.Key {
+				tv, vv := te.Value(), ve.Value()
+				if tv.Type == bson.TypeEmbeddedDocument && vv.Type == bson.TypeEmbeddedDocument {
+					data, err := mergeBSON(tv.Value, vv.Value)
+					if err != nil {
+						return nil, err
+					}
+					e.Value = bson.RawValue{Type: bson.TypeEmbeddedDocument, Value: data}
+				} else {
+					e.Value = tv
+				}
+				used[i] = true
+				break
+			}
+		}
+		merged = append(merged, e)
+	}
+
+	prefix := make(bson.D, 0, len(tagElements))
+	for i, te := range tagElements {
+		if !used[i] {
+			prefix = append(prefix, bson.E{Key: te.Key(), Value: te.Value()})
+		}
+	}
+	return bson.Marshal(append(prefix, merged...))
+}
@@ -0,0 +1,136 @@
+package ijson_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/Nikkolix/ijson"
+)
+
+type PetT struct {
+	T string `bson:"_t"`
+}
+
+func registerPetsT(t *testing.T) {
+	t.Helper()
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterT[Dog, Pet](PetT{T: "dog"}))
+	require.NoError(t, ijson.RegisterT[Cat, Pet](PetT{T: "cat"}))
+}
+
+func TestDecodable_BSON_RoundTrip(t *testing.T) {
+	registerPetsT(t)
+
+	type Owner struct {
+		Name string                        `bson:"name"`
+		Best ijson.RDecodable[Pet, PetT]   `bson:"best"`
+		None ijson.RDecodable[Pet, PetT]   `bson:"none"`
+		Pets []ijson.RDecodable[Pet, PetT] `bson:"pets"`
+	}
+
+	in := Owner{
+		Name: "jon",
+		Best: ijson.RDecodable[Pet, PetT]{I: &Dog{Name: "rex"}},
+		Pets: []ijson.RDecodable[Pet, PetT]{{I: &Cat{Lives: 9}}, {I: &Dog{Name: "odie"}}},
+	}
+	data, err := bson.Marshal(in)
+	require.NoError(t, err)
+	assert.Equal(t, `{"name": "jon","best": {"_t": "dog","name": "rex"},"none": null,"pets": [{"_t": "cat","lives": {"$numberInt":"9"}},{"_t": "dog","name": "odie"}]}`, bson.Raw(data).String())
+
+	var out Owner
+	require.NoError(t, bson.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+
+	data, err = bson.Marshal(ijson.RDecodable[Pet, PetT]{I: &Cat{Lives: 3}})
+	require.NoError(t, err)
+	var d ijson.RDecodable[Pet, PetT]
+	require.NoError(t, bson.Unmarshal(data, &d))
+	assert.Equal(t, &Cat{Lives: 3}, d.I)
+}
+
+func TestDecodable_BSON_DecodableF(t *testing.T) {
+	registerPets(t)
+	require.NoError(t, ijson.RegisterF[Pet, TestFSelector]("dog", func() Pet { return &Dog{} }))
+	require.NoError(t, ijson.RegisterF[Pet, MetadataKind]("cat", func() Pet { return &Cat{} }))
+
+	data, err := bson.Marshal(bson.D{{Key: "type", Value: "dog"}, {Key: "name", Value: "rex"}, {Key: "extra", Value: bson.A{1, 2.5}}})
+	require.NoError(t, err)
+	var f ijson.DecodableF[Pet, TestFSelector, string]
+	require.NoError(t, bson.Unmarshal(data, &f))
+	assert.Equal(t, &Dog{Name: "rex"}, f.I)
+	data, err = bson.Marshal(f)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type": "dog","name": "rex"}`, bson.Raw(data).String())
+
+	data, err = bson.Marshal(ijson.DecodableF[Pet, MetadataKind, string]{I: &Cat{Lives: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, `{"metadata": {"kind": "cat"},"lives": {"$numberInt":"3"}}`, bson.Raw(data).String())
+	var nested ijson.DecodableF[Pet, MetadataKind, string]
+	require.NoError(t, bson.Unmarshal(data, &nested))
+	assert.Equal(t, &Cat{Lives: 3}, nested.I)
+
+	data, err = bson.Marshal(bson.D{{Key: "metadata", Value: "cat"}})
+	require.NoError(t, err)
+	err = bson.Unmarshal(data, &nested)
+	assert.ErrorIs(t, err, ijson.ErrMissingDiscriminator)
+	assert.EqualError(t, err, "bson: decide ijson_test.Pet for X value /metadata/kind=<missing>: discriminator field /metadata/kind not found")
+}
+
+func TestDecodable_BSON_DecodableM(t *testing.T) {
+	registerObjects(t)
+
+	data, err := bson.Marshal(ijson.DecodableM[Object, APIKind, [2]string]{I: &Deployment{Replicas: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, `{"apiVersion": "apps/v1","kind": "Deployment","metadata": {"name": ""},"replicas": {"$numberInt":"2"}}`, bson.Raw(data).String())
+
+	var o ijson.DecodableM[Object, APIKind, [2]string]
+	require.NoError(t, bson.Unmarshal(data, &o))
+	assert.Equal(t, &Deployment{Replicas: 2}, o.I)
+
+	data, err = bson.Marshal(bson.D{{Key: "kind", Value: "Deployment"}})
+	require.NoError(t, err)
+	assert.ErrorIs(t, bson.Unmarshal(data, &o), ijson.ErrMissingDiscriminator)
+}
+
+func TestDecodable_BSON_Errors(t *testing.T) {
+	registerPetsT(t)
+
+	encode := func(d bson.D) []byte {
+		data, err := bson.Marshal(d)
+		require.NoError(t, err)
+		return data
+	}
+
+	tests := []struct {
+		name  string
+		data  []byte
+		phase ijson.Phase
+		err   string
+	}{
+		{name: "invalid discriminator", data: encode(bson.D{{Key: "_t", Value: 1}}), phase: ijson.PhaseDiscriminator, err: "cannot decode 32-bit integer into a string type"},
+		{name: "unknown", data: encode(bson.D{{Key: "_t", Value: "fish"}}), phase: ijson.PhaseDecide, err: "bson: decide ijson_test.Pet: no factory found"},
+		{name: "invalid payload", data: encode(bson.D{{Key: "_t", Value: "cat"}, {Key: "lives", Value: "nine"}}), phase: ijson.PhasePayload, err: "bson: decode *ijson_test.Cat for X value {cat}:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d ijson.RDecodable[Pet, PetT]
+			err := bson.Unmarshal(tt.data, &d)
+			var decodeErr *ijson.DecodeError
+			require.ErrorAs(t, err, &decodeErr)
+			assert.Equal(t, tt.phase, decodeErr.Phase)
+			assert.Equal(t, "bson", decodeErr.Codec)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	var d ijson.RDecodable[Pet, PetT]
+	err := d.UnmarshalBSONValue(bson.TypeString, []byte{2, 0, 0, 0, 'a', 0})
+	assert.EqualError(t, err, "bson: decode discriminator ijson_test.PetT: cannot decode BSON string into a document")
+
+	var f ijson.DecodableF[Pet, TestFSelector, string]
+	assert.Error(t, f.UnmarshalBSON([]byte{1, 2}))
+}
@@ -0,0 +1,384 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CBOR major types used by the scanner.
+const (
+	cborBytes = 2
+	cborText  = 3
+	cborArray = 4
+	cborMap   = 5
+	cborTag   = 6
+	cborOther = 7
+)
+
+// cborField is an entry of a CBOR map with its key and value kept as raw bytes.
+type cborField struct {
+	key   []byte
+	value []byte
+}
+
+var errCBORSyntax = errors.New("ijson: invalid cbor")
+
+// splitCBORMap splits a CBOR map into its entries without decoding them.
+// It reports false if data is not a single CBOR map.
+func splitCBORMap(data []byte) ([]cborField, bool) {
+	s := cborScanner{data: data}
+	fields, err := s.mapFields(nil)
+	if err != nil || s.pos != len(s.data) {
+		return nil, false
+	}
+	return fields, true
+}
+
+// findCBORField returns the value of the last entry of fields with the given text key.
+func findCBORField(fields []cborField, key string) ([]byte, bool) {
+	keys := [][]byte{[]byte(key)}
+	var value []byte
+	found := false
+	for _, f := range fields {
+		if matchCBORKey(f.key, keys) {
+			value, found = f.value, true
+		}
+	}
+	return value, found
+}
+
+// isCBORNull reports whether data is the CBOR null or undefined value.
+func isCBORNull(data []byte) bool {
+	return len(data) == 1 && (data[0] == 0xf6 || data[0] == 0xf7)
+}
+
+// isCBORMap reports whether data starts with a CBOR map.
+func isCBORMap(data []byte) bool {
+	return len(data) > 0 && data[0]>>5 == cborMap
+}
+
+// cborMapError returns the error cbor reports for data that is not a CBOR map.
+func cborMapError(data []byte) error {
+	var m map[any]cbor.RawMessage
+	if err := cbor.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	return errCBORSyntax
+}
+
+// lookupCBOR returns the value at path in the CBOR map data.
+// It reports false if a key is missing or a value on the path is not a map.
+// An error is returned only if data is not a map.
+func lookupCBOR(data []byte, path []string) ([]byte, bool, error) {
+	for i, key := range path {
+		s := cborScanner{data: data}
+		fields, err := s.mapFields([][]byte{[]byte(key)})
+		if err != nil || s.pos != len(s.data) {
+			if i == 0 {
+				return nil, false, cborMapError(data)
+			}
+			return nil, false, nil
+		}
+
+		var ok bool
+		data, ok = findCBORField(fields, key)
+		if !ok {
+			return nil, false, nil
+		}
+	}
+	return data, true, nil
+}
+
+// lookupCBORPaths returns the values at paths in the CBOR map data, scanning each map once.
+// The value of a path is nil if a key is missing or a value on the path is not a map.
+// An error is returned only if data is not a map.
+func lookupCBORPaths(data []byte, paths [][]string) ([][]byte, error) {
+	values := make([][]byte, len(paths))
+	ok := lookupPaths(data, paths, values, func(data []byte, keys []string) (func(string) ([]byte, bool), bool) {
+		s := cborScanner{data: data}
+		fields, err := s.mapFields(byteKeys(keys))
+		if err != nil || s.pos != len(s.data) {
+			return nil, false
+		}
+		return func(key string) ([]byte, bool) { return findCBORField(fields, key) }, true
+	})
+	if !ok {
+		return nil, cborMapError(data)
+	}
+	return values, nil
+}
+
+// nestCBOR wraps the CBOR value data into maps along path.
+func nestCBOR(data []byte, path []string) []byte {
+	for i := len(path) - 1; i >= 0; i-- {
+		key := appendCBORHead(nil, cborText, uint64(len(path[i])))
+		key = append(key, path[i]...)
+		data = encodeCBORMap([]cborField{{key: key, value: data}})
+	}
+	return data
+}
+
+// mergeCBOR merges the entries of the CBOR map tag into the CBOR map value.
+// Entries of value that also exist in tag are replaced in place, or merged if both are maps,
+// all other entries of tag are prepended.
+// If either input is not a map, value is returned unchanged.
+func mergeCBOR(tag, value []byte) []byte {
+	tagFields, ok := splitCBORMap(tag)
+	if !ok {
+		return value
+	}
+	valueFields, ok := splitCBORMap(value)
+	if !ok {
+		return value
+	}
+
+	merged := make([]cborField, 0, len(tagFields)+len(valueFields))
+	used := make([]bool, len(tagFields))
+	for _, vf := range valueFields {
+		for i, tf := range tagFields {
+			if bytes.Equal(tf.key, vf.key) {
+				if isCBORMap(tf.value) && isCBORMap(vf.value) {
+					vf.value = mergeCBOR(tf.value, vf.value)
+				} else {
+					vf.value = tf.value
+				}
+				used[i] = true
+				break
+			}
+		}
+		merged = append(merged, vf)
+	}
+
+	prefix := make([]cborField, 0, len(tagFields))
+	for i, tf := range tagFields {
+		if !used[i] {
+			prefix = append(prefix, tf)
+		}
+	}
+	return encodeCBORMap(append(prefix, merged...))
+}
+
+// encodeCBORMap writes the fields as a definite length CBOR map.
+func encodeCBORMap(fields []cborField) []byte {
+	size := 9
+	for _, f := range fields {
+		size += len(f.key) + len(f.value)
+	}
+
+	buf := appendCBORHead(make([]byte, 0, size), cborMap, uint64(len(fields)))
+	for _, f := range fields {
+		buf = append(buf, f.key...)
+		buf = append(buf, f.value...)
+	}
+	return buf
+}
+
+// appendCBORHead appends the head of a data item of the given major type and argument to buf.
+func appendCBORHead(buf []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= 0xff:
+		return append(buf, major|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(buf, major|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(buf, major|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(buf, major|27), n)
+}
+
+// maxCBORDepth limits the nesting of scanned CBOR values.
+const maxCBORDepth = 10000
+
+// cborScanner is a minimal CBOR scanner that only records value boundaries.
+type cborScanner struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+// mapFields scans a CBOR map and returns its entries.
+// If keys is set, only entries with a definite length text key equal to one of keys are returned.
+func (s *cborScanner) mapFields(keys [][]byte) ([]cborField, error) {
+	major, n, indefinite, err := s.head()
+	if err != nil {
+		return nil, err
+	}
+	if major != cborMap {
+		return nil, errCBORSyntax
+	}
+
+	var fields []cborField
+	for i := uint64(0); indefinite || i < n; i++ {
+		if indefinite && s.isBreak() {
+			s.pos++
+			break
+		}
+
+		key, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		value, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		if keys == nil || matchCBORKey(key, keys) {
+			fields = append(fields, cborField{key: key, value: value})
+		}
+	}
+	return fields, nil
+}
+
+// matchCBORKey reports whether the raw CBOR value key is a definite length text string equal to one of keys.
+func matchCBORKey(key []byte, keys [][]byte) bool {
+	s := cborScanner{data: key}
+	major, n, indefinite, err := s.head()
+	if err != nil || major != cborText || indefinite || uint64(len(key)-s.pos) != n {
+		return false
+	}
+
+	name := key[s.pos:]
+	for _, k := range keys {
+		if bytes.Equal(name, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// head reads the head of a data item and returns its major type and argument.
+// For indefinite length strings, arrays and maps the argument is 0 and indefinite is true.
+func (s *cborScanner) head() (major byte, n uint64, indefinite bool, err error) {
+	if s.pos >= len(s.data) {
+		return 0, 0, false, errCBORSyntax
+	}
+	c := s.data[s.pos]
+	s.pos++
+
+	major, info := c>>5, c&0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), false, nil
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(s.data)-s.pos < size {
+			return 0, 0, false, errCBORSyntax
+		}
+		b := s.data[s.pos : s.pos+size]
+		s.pos += size
+		switch size {
+		case 1:
+			n = uint64(b[0])
+		case 2:
+			n = uint64(binary.BigEndian.Uint16(b))
+		case 4:
+			n = uint64(binary.BigEndian.Uint32(b))
+		default:
+			n = binary.BigEndian.Uint64(b)
+		}
+		return major, n, false, nil
+	case info == 31 && major >= cborBytes && major <= cborMap:
+		return major, 0, true, nil
+	}
+	return 0, 0, false, errCBORSyntax
+}
+
+// value scans a single CBOR data item and returns its raw bytes.
+func (s *cborScanner) value() ([]byte, error) {
+	start := s.pos
+	major, n, indefinite, err := s.head()
+	if err != nil {
+		return nil, err
+	}
+
+	// every item takes at least one byte, which also keeps 2*n from overflowing
+	if (major == cborArray || major == cborMap) && !indefinite && n > uint64(len(s.data)-s.pos) {
+		return nil, errCBORSyntax
+	}
+
+	switch {
+	case indefinite:
+		err = s.indefinite(major)
+	case major == cborBytes, major == cborText:
+		err = s.skip(n)
+	case major == cborArray:
+		err = s.values(n)
+	case major == cborMap:
+		err = s.values(2 * n)
+	case major == cborTag:
+		err = s.values(1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.data[start:s.pos], nil
+}
+
+// indefinite scans the items of an indefinite length value up to and including the break code.
+func (s *cborScanner) indefinite(major byte) error {
+	s.depth++
+	if s.depth > maxCBORDepth {
+		return errCBORSyntax
+	}
+
+	for !s.isBreak() {
+		if s.pos >= len(s.data) {
+			return errCBORSyntax
+		}
+		if major == cborBytes || major == cborText {
+			// chunks must be definite length strings of the same major type
+			chunk, n, indefinite, err := s.head()
+			if err != nil || chunk != major || indefinite {
+				return errCBORSyntax
+			}
+			if err := s.skip(n); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := s.value(); err != nil {
+			return err
+		}
+	}
+	s.pos++
+	s.depth--
+	return nil
+}
+
+// isBreak reports whether the next byte is the break code ending an indefinite length value.
+func (s *cborScanner) isBreak() bool {
+	return s.pos < len(s.data) && s.data[s.pos] == cborOther<<5|31
+}
+
+func (s *cborScanner) values(n uint64) error {
+	s.depth++
+	if s.depth > maxCBORDepth {
+		return errCBORSyntax
+	}
+
+	for range n {
+		if _, err := s.value(); err != nil {
+			return err
+		}
+	}
+	s.depth--
+	return nil
+}
+
+func (s *cborScanner) skip(n uint64) error {
+	if uint64(len(s.data)-s.pos) < n {
+		return errCBORSyntax
+	}
+	s.pos += int(n)
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+var (
+	_ cbor.Marshaler   = CBORTagDecodable[any, uint64, RegistryDecider[any, uint64]]{}
+	_ cbor.Unmarshaler = &CBORTagDecodable[any, uint64, RegistryDecider[any, uint64]]{}
+)
+
+// CBORTagDecodable is a generic wrapper for polymorphic (de)serialization of CBOR data items
+// whose tag number is the discriminator: 1000({"name": "Fido"}).
+// I is the interface type, X is the discriminator type and D is the decider.
+// Marshaling requires D to implement Discriminator.
+// Choose tag numbers that are not assigned by IANA, so other decoders do not interpret the content.
+type CBORTagDecodable[I any, X ~uint64, D Decider[I, X]] struct {
+	I I // The decoded value implementing I
+}
+
+// RCBORTagDecodable is a type alias for CBORTagDecodable using RegistryDecider.
+type RCBORTagDecodable[I any, X ~uint64] = CBORTagDecodable[I, X, RegistryDecider[I, X]]
+
+// MarshalCBOR marshals the contained value as the content of a CBOR tag numbered by its discriminator.
+func (d CBORTagDecodable[I, X, D]) MarshalCBOR() ([]byte, error) {
+	if any(d.I) == nil {
+		return cbor.Marshal(nil)
+	}
+
+	x, err := mustDiscriminate[I, X, D](d.I)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := cbor.Marshal(d.I)
+	if err != nil {
+		return nil, err
+	}
+	return cbor.RawTag{Number: uint64(x), Content: content}.MarshalCBOR()
+}
+
+// UnmarshalCBOR does unmarshal the content of the CBOR tag in data into the contained value,
+// using the tag number as discriminator.
+// Null and undefined decode as nil, any other data item without a tag fails with an error
+// matching ErrMissingDiscriminator.
+func (d *CBORTagDecodable[I, X, D]) UnmarshalCBOR(data []byte) error {
+	if isCBORNull(data) {
+		var i I
+		d.I = i
+		return nil
+	}
+
+	s := cborScanner{data: data}
+	major, _, _, err := s.head()
+	if err != nil || major != cborTag {
+		return discriminatorError[I, X](codecCBOR, fmt.Errorf("%w: data item has no tag", ErrMissingDiscriminator))
+	}
+
+	var tag cbor.RawTag
+	if err := cbor.Unmarshal(data, &tag); err != nil {
+		return discriminatorError[I, X](codecCBOR, err)
+	}
+
+	x := X(tag.Number)
+	var decider D
+	d.I, err = decider.Decide(x)
+	if err != nil {
+		return decideError[I](codecCBOR, x, err)
+	}
+	return payloadError(codecCBOR, x, d.I, cbor.Unmarshal(tag.Content, d.I))
+}
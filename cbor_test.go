@@ -0,0 +1,169 @@
+package ijson_test
+
+import (
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Nikkolix/ijson"
+)
+
+// diagnose returns the CBOR diagnostic notation of data.
+func diagnose(t *testing.T, data []byte) string {
+	t.Helper()
+	diag, err := cbor.Diagnose(data)
+	require.NoError(t, err)
+	return diag
+}
+
+func encodeCBOR(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := cbor.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
+
+func TestDecodable_CBOR_RoundTrip(t *testing.T) {
+	registerPets(t)
+
+	type Owner struct {
+		Pets []ijson.RDecodable[Pet, PetKind] `cbor:"pets"`
+	}
+
+	in := Owner{Pets: []ijson.RDecodable[Pet, PetKind]{{I: &Dog{Name: "rex"}}, {I: &Cat{Lives: 9}}}}
+	data, err := cbor.Marshal(in)
+	require.NoError(t, err)
+	assert.Equal(t, `{"pets": [{"type": "dog", "name": "rex"}, {"type": "cat", "lives": 9}]}`, diagnose(t, data))
+
+	var out Owner
+	require.NoError(t, cbor.Unmarshal(data, &out))
+	require.Len(t, out.Pets, 2)
+	assert.Equal(t, &Dog{Name: "rex"}, out.Pets[0].I)
+	assert.Equal(t, &Cat{Lives: 9}, out.Pets[1].I)
+}
+
+func TestDecodable_CBOR_SkipsOtherFields(t *testing.T) {
+	registerPets(t)
+
+	// {_ "lives": 3, "extra": [1, {"type": 2}], "type": "cat"} as indefinite length map
+	data := []byte{0xbf}
+	for _, v := range []any{"lives", 3, "extra", []any{1, map[string]int{"type": 2}}, "type", "cat"} {
+		data = append(data, encodeCBOR(t, v)...)
+	}
+	data = append(data, 0xff)
+
+	var d ijson.RDecodable[Pet, PetKind]
+	require.NoError(t, cbor.Unmarshal(data, &d))
+	assert.Equal(t, &Cat{Lives: 3}, d.I)
+}
+
+func TestDecodable_CBOR_DecodableF(t *testing.T) {
+	registerPets(t)
+	require.NoError(t, ijson.RegisterF[Pet, TestFSelector]("dog", func() Pet { return &Dog{} }))
+	require.NoError(t, ijson.RegisterF[Pet, MetadataKind]("cat", func() Pet { return &Cat{} }))
+
+	data, err := cbor.Marshal(map[string]any{"type": "dog", "name": "rex", "extra": []any{1, 2.5}})
+	require.NoError(t, err)
+	var f ijson.DecodableF[Pet, TestFSelector, string]
+	require.NoError(t, cbor.Unmarshal(data, &f))
+	assert.Equal(t, &Dog{Name: "rex"}, f.I)
+	data, err = cbor.Marshal(f)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type": "dog", "name": "rex"}`, diagnose(t, data))
+
+	data, err = cbor.Marshal(ijson.DecodableF[Pet, MetadataKind, string]{I: &Cat{Lives: 3}})
+	require.NoError(t, err)
+	assert.Equal(t, `{"metadata": {"kind": "cat"}, "lives": 3}`, diagnose(t, data))
+	var nested ijson.DecodableF[Pet, MetadataKind, string]
+	require.NoError(t, cbor.Unmarshal(data, &nested))
+	assert.Equal(t, &Cat{Lives: 3}, nested.I)
+
+	data, err = cbor.Marshal(map[string]any{"name": "rex"})
+	require.NoError(t, err)
+	err = cbor.Unmarshal(data, &f)
+	assert.ErrorIs(t, err, ijson.ErrMissingDiscriminator)
+	assert.EqualError(t, err, "cbor: decide ijson_test.Pet for X value type=<missing>: discriminator field type not found")
+}
+
+func TestDecodable_CBOR_DecodableM(t *testing.T) {
+	registerObjects(t)
+
+	data, err := cbor.Marshal(ijson.DecodableM[Object, APIKind, [2]string]{I: &Deployment{Replicas: 2}})
+	require.NoError(t, err)
+	assert.Equal(t, `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": ""}, "replicas": 2}`, diagnose(t, data))
+
+	var o ijson.DecodableM[Object, APIKind, [2]string]
+	require.NoError(t, cbor.Unmarshal(data, &o))
+	assert.Equal(t, &Deployment{Replicas: 2}, o.I)
+
+	data, err = cbor.Marshal(map[string]any{"kind": "Deployment"})
+	require.NoError(t, err)
+	assert.ErrorIs(t, cbor.Unmarshal(data, &o), ijson.ErrMissingDiscriminator)
+}
+
+func TestDecodable_CBOR_Errors(t *testing.T) {
+	registerPets(t)
+
+	tests := []struct {
+		name  string
+		data  []byte
+		phase ijson.Phase
+		err   string
+	}{
+		{name: "not a map", data: encodeCBOR(t, []string{"dog"}), phase: ijson.PhaseDiscriminator, err: "cannot unmarshal array into Go value of type ijson_test.PetKind"},
+		{name: "unknown", data: encodeCBOR(t, map[string]any{"type": "fish"}), phase: ijson.PhaseDecide, err: "cbor: decide ijson_test.Pet: no factory found"},
+		{name: "invalid payload", data: encodeCBOR(t, map[string]any{"type": "cat", "lives": "nine"}), phase: ijson.PhasePayload, err: "cbor: decode *ijson_test.Cat for X value {cat}: cbor: cannot unmarshal UTF-8 text string"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d ijson.RDecodable[Pet, PetKind]
+			err := cbor.Unmarshal(tt.data, &d)
+			var decodeErr *ijson.DecodeError
+			require.ErrorAs(t, err, &decodeErr)
+			assert.Equal(t, tt.phase, decodeErr.Phase)
+			assert.Equal(t, "cbor", decodeErr.Codec)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	var f ijson.DecodableF[Pet, TestFSelector, string]
+	assert.ErrorContains(t, cbor.Unmarshal(encodeCBOR(t, []string{"dog"}), &f), "cannot unmarshal array")
+}
+
+type PetNumber uint64
+
+func TestCBORTagDecodable(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.Register[Pet, PetNumber](1000, func() Pet { return &Dog{} }))
+	require.NoError(t, ijson.Register[Pet, PetNumber](1001, func() Pet { return &Cat{} }))
+
+	in := []ijson.RCBORTagDecodable[Pet, PetNumber]{{I: &Dog{Name: "Fido"}}, {I: &Cat{Lives: 9}}, {}}
+	data, err := cbor.Marshal(in)
+	require.NoError(t, err)
+	assert.Equal(t, `[1000({"name": "Fido"}), 1001({"lives": 9}), null]`, diagnose(t, data))
+
+	var out []ijson.RCBORTagDecodable[Pet, PetNumber]
+	require.NoError(t, cbor.Unmarshal(data, &out))
+	require.Len(t, out, 3)
+	assert.Equal(t, &Dog{Name: "Fido"}, out[0].I)
+	assert.Equal(t, &Cat{Lives: 9}, out[1].I)
+	assert.Nil(t, out[2].I)
+
+	var d ijson.RCBORTagDecodable[Pet, PetNumber]
+	var decodeErr *ijson.DecodeError
+	err = cbor.Unmarshal(encodeCBOR(t, map[string]any{"name": "Fido"}), &d)
+	assert.ErrorIs(t, err, ijson.ErrMissingDiscriminator)
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, ijson.PhaseDiscriminator, decodeErr.Phase)
+
+	err = cbor.Unmarshal(encodeCBOR(t, cbor.Tag{Number: 1002, Content: map[string]any{}}), &d)
+	assert.ErrorIs(t, err, ijson.ErrNotRegistered)
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, PetNumber(1002), decodeErr.Value)
+
+	_, err = cbor.Marshal(ijson.RCBORTagDecodable[Pet, PetNumber]{I: &Fish{}})
+	assert.ErrorContains(t, err, "no discriminator found for type *ijson_test.Fish")
+}
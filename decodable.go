@@ -3,18 +3,22 @@
 // that can be found in the LICENSE file.
 
 // Package ijson provides generic, discriminator-based polymorphic unmarshaling
-// for JSON and MessagePack.
-// It supports multiple strategies for type resolution
-// and works with both encoding/json and vmihailenco/msgpack.
+// for JSON, MessagePack, YAML, CBOR, BSON and XML.
+// It supports multiple strategies for type resolution and works with encoding/json, encoding/xml,
+// vmihailenco/msgpack, gopkg.in/yaml.v3, fxamacker/cbor and the bson package of the MongoDB driver.
 package ijson
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"reflect"
-	"sync"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/vmihailenco/msgpack/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -25,8 +29,33 @@ var (
 	_ msgpack.Marshaler   = Decodable[any, any, RegistryDecider[any, any]]{}
 	_ msgpack.Marshaler   = &Decodable[any, any, RegistryDecider[any, any]]{}
 	_ msgpack.Unmarshaler = &Decodable[any, any, RegistryDecider[any, any]]{}
+
+	_ msgpack.CustomEncoder = Decodable[any, any, RegistryDecider[any, any]]{}
+	_ msgpack.CustomEncoder = &Decodable[any, any, RegistryDecider[any, any]]{}
+
+	_ yaml.Marshaler   = Decodable[any, any, RegistryDecider[any, any]]{}
+	_ yaml.Unmarshaler = &Decodable[any, any, RegistryDecider[any, any]]{}
+
+	_ cbor.Marshaler   = Decodable[any, any, RegistryDecider[any, any]]{}
+	_ cbor.Unmarshaler = &Decodable[any, any, RegistryDecider[any, any]]{}
+
+	_ bson.Marshaler        = Decodable[any, any, RegistryDecider[any, any]]{}
+	_ bson.Unmarshaler      = &Decodable[any, any, RegistryDecider[any, any]]{}
+	_ bson.ValueMarshaler   = Decodable[any, any, RegistryDecider[any, any]]{}
+	_ bson.ValueUnmarshaler = &Decodable[any, any, RegistryDecider[any, any]]{}
+
+	_ xml.Marshaler   = Decodable[any, any, RegistryDecider[any, any]]{}
+	_ xml.Unmarshaler = &Decodable[any, any, RegistryDecider[any, any]]{}
+
+	_ Discriminator[any, any]                    = RegistryDecider[any, any]{}
+	_ Discriminator[any, FValue[fieldType, any]] = FDecider[any, fieldType, any]{}
 )
 
+// fieldType is a field selector used for compile time assertions.
+type fieldType struct{}
+
+func (fieldType) FieldName() string { return "type" }
+
 // Decider is a generic interface that determines the concrete type to instantiate
 // based on a discriminator value.
 // It enables polymorphic deserialization by
@@ -38,6 +67,14 @@ type Decider[I, X any] interface {
 	~struct{}
 }
 
+// Discriminator is an optional interface for deciders that can map a value
+// back to the discriminator it was registered with.
+// Decodable uses it to emit the discriminator when marshaling.
+type Discriminator[I, X any] interface {
+	// Discriminate returns the discriminator for i and whether one is known.
+	Discriminate(i I) (X, bool)
+}
+
 // Decodable is a generic wrapper for polymorphic (de)serialization.
 // I is the interface type, X is the discriminator type, D is the decider.
 type Decodable[I any, X any, D Decider[I, X]] struct {
@@ -45,199 +82,362 @@ type Decodable[I any, X any, D Decider[I, X]] struct {
 }
 
 // MarshalMsgpack marshals the contained value using msgpack.
+// If the decider implements Discriminator, the discriminator is merged into the emitted map.
 func (d Decodable[I, X, D]) MarshalMsgpack() ([]byte, error) {
-	return msgpack.Marshal(d.I)
+	data, err := msgpack.Marshal(d.I)
+	if err != nil {
+		return nil, err
+	}
+
+	x, ok := discriminate[I, X, D](d.I)
+	if !ok {
+		return data, nil
+	}
+
+	tag, err := msgpack.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	return mergeMsgpack(tag, data)
 }
 
 // MarshalJSON marshals the contained value using JSON.
+// If the decider implements Discriminator, the discriminator is merged into the emitted object.
 func (d Decodable[I, X, D]) MarshalJSON() ([]byte, error) {
-	return json.Marshal(d.I)
+	data, err := json.Marshal(d.I)
+	if err != nil {
+		return nil, err
+	}
+
+	x, ok := discriminate[I, X, D](d.I)
+	if !ok {
+		return data, nil
+	}
+
+	tag, err := json.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	return mergeJSON(tag, data), nil
+}
+
+// discriminate returns the discriminator for i if the decider D implements Discriminator.
+func discriminate[I any, X any, D Decider[I, X]](i I) (X, bool) {
+	var x X
+	if any(i) == nil {
+		return x, false
+	}
+
+	discriminator, ok := any(*new(D)).(Discriminator[I, X])
+	if !ok {
+		return x, false
+	}
+	return discriminator.Discriminate(i)
+}
+
+// EncodeMsgpack encodes the contained value with the settings of the msgpack encoder, like its struct tag.
+// If the decider implements Discriminator, the discriminator is merged into the emitted map.
+func (d Decodable[I, X, D]) EncodeMsgpack(enc *msgpack.Encoder) error {
+	x, ok := discriminate[I, X, D](d.I)
+	if !ok {
+		return enc.Encode(d.I)
+	}
+
+	data, err := encodeMsgpackWith(enc, d.I)
+	if err != nil {
+		return err
+	}
+	tag, err := encodeMsgpackWith(enc, x)
+	if err != nil {
+		return err
+	}
+	merged, err := mergeMsgpack(tag, data)
+	if err != nil {
+		return err
+	}
+	_, err = enc.Writer().Write(merged)
+	return err
 }
 
 // UnmarshalMsgpack does unmarshal data into the contained value using msgpack.
 // It uses the decider to resolve the concrete type based on the discriminator.
+// When X is a struct, the discriminator is decoded from only the map entries X can hold;
+// all other entries are skipped by inspecting their keys, so the payload is fully decoded once,
+// into the concrete type.
 func (d *Decodable[I, X, D]) UnmarshalMsgpack(data []byte) error {
+	tag, err := discriminatorMsgpack[X](data)
+	if err != nil {
+		return discriminatorError[I, X](codecMsgpack, err)
+	}
+
 	x := new(X)
-	err := msgpack.Unmarshal(data, x)
+	err = msgpack.Unmarshal(tag, x)
 	if err != nil {
-		return err
+		return discriminatorError[I, X](codecMsgpack, err)
 	}
 
 	var decider D
 	d.I, err = decider.Decide(*x)
 	if err != nil {
-		return err
+		return decideError[I](codecMsgpack, *x, err)
 	}
-	return msgpack.Unmarshal(data, d.I)
+	return payloadError(codecMsgpack, *x, d.I, msgpack.Unmarshal(data, d.I))
 }
 
 // UnmarshalJSON does unmarshal data into the contained value using JSON.
 // It uses the decider to resolve the concrete type based on the discriminator.
+// When X is a struct, the discriminator is decoded from only the members X can hold,
+// so the payload is fully decoded once, into the concrete type. The document is still
+// tokenized twice, by that scan and by encoding/json.
 func (d *Decodable[I, X, D]) UnmarshalJSON(data []byte) error {
 	x := new(X)
-	err := json.Unmarshal(data, x)
+	b := jsonBufferPool.Get().(*jsonBuffers)
+	err := json.Unmarshal(discriminatorJSON[X](b, data), x)
+	b.release()
 	if err != nil {
-		return err
+		return discriminatorError[I, X](codecJSON, err)
 	}
 
 	var decider D
 	d.I, err = decider.Decide(*x)
 	if err != nil {
-		return err
+		return decideError[I](codecJSON, *x, err)
 	}
-	return json.Unmarshal(data, d.I)
+	return payloadError(codecJSON, *x, d.I, json.Unmarshal(data, d.I))
 }
 
-// xAdapter adapts XDecider to Decider for generic use.
-type xAdapter[I any, X XDecider[I, X]] struct{}
+// MarshalYAML marshals the contained value into a YAML node.
+// If the decider implements Discriminator, the discriminator is merged into the emitted mapping.
+func (d Decodable[I, X, D]) MarshalYAML() (any, error) {
+	var value yaml.Node
+	if err := value.Encode(d.I); err != nil {
+		return nil, err
+	}
 
-func (xAdapter[I, X]) Decide(x X) (I, error) {
-	return x.Decide()
-}
+	x, ok := discriminate[I, X, D](d.I)
+	if !ok {
+		return &value, nil
+	}
 
-// XDecider is an interface for types that can decide their own concrete type.
-type XDecider[I, X any] interface {
-	Decide() (I, error)
-	any
+	var tag yaml.Node
+	if err := tag.Encode(x); err != nil {
+		return nil, err
+	}
+	return mergeYAML(&tag, &value), nil
 }
 
-// XDecodable is a type alias for Decodable using xAdapter.
-type XDecodable[I any, X XDecider[I, X]] = Decodable[I, X, xAdapter[I, X]]
-
-// RDecodable is a type alias for Decodable using RegistryDecider.
-type RDecodable[I any, X comparable] = Decodable[I, X, RegistryDecider[I, X]]
+// UnmarshalYAML decodes the YAML node into the contained value.
+// It uses the decider to resolve the concrete type based on the discriminator decoded from the same node,
+// so the document is parsed once. When X is a struct, fields of the node that X does not hold are ignored.
+func (d *Decodable[I, X, D]) UnmarshalYAML(node *yaml.Node) error {
+	x := new(X)
+	err := node.Decode(x)
+	if err != nil {
+		return discriminatorError[I, X](codecYAML, err)
+	}
 
-// typeKey is a unique key to get the registry for types I and X with a value of X
-type typeKey[I any, X comparable] struct {
-	x X
+	var decider D
+	d.I, err = decider.Decide(*x)
+	if err != nil {
+		return decideError[I](codecYAML, *x, err)
+	}
+	if any(d.I) == nil {
+		// yaml panics when decoding into nil
+		return payloadError(codecYAML, *x, d.I, fmt.Errorf("cannot decode into nil %s", reflect.TypeFor[I]()))
+	}
+	return payloadError(codecYAML, *x, d.I, node.Decode(d.I))
 }
 
-var registries = map[any]any{} // map[typeKey[I, X]]func() I
-var mutex = sync.RWMutex{}
+// MarshalCBOR marshals the contained value using CBOR.
+// If the decider implements Discriminator, the discriminator is merged into the emitted map.
+func (d Decodable[I, X, D]) MarshalCBOR() ([]byte, error) {
+	data, err := cbor.Marshal(d.I)
+	if err != nil {
+		return nil, err
+	}
+
+	x, ok := discriminate[I, X, D](d.I)
+	if !ok {
+		return data, nil
+	}
 
-// ResetRegistries clears all registered types. Useful for tests.
-func ResetRegistries() {
-	mutex.Lock()
-	defer mutex.Unlock()
-	clear(registries)
+	tag, err := cbor.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	return mergeCBOR(tag, data), nil
 }
 
-// RegisterT registers a type T for interface I and discriminator X.
-// T must not be a pointer and must implement I.
-func RegisterT[T any, I any, X comparable](x X) error {
-	if reflect.TypeFor[T]().Kind() == reflect.Pointer {
-		return fmt.Errorf("factory type %T must not be a pointer", *new(T))
+// UnmarshalCBOR does unmarshal data into the contained value using CBOR.
+// It uses the decider to resolve the concrete type based on the discriminator.
+// When X is a struct, cbor decodes only the map entries X holds and skips all others,
+// so the payload is fully decoded once, into the concrete type.
+func (d *Decodable[I, X, D]) UnmarshalCBOR(data []byte) error {
+	x := new(X)
+	err := cbor.Unmarshal(data, x)
+	if err != nil {
+		return discriminatorError[I, X](codecCBOR, err)
 	}
 
-	if _, ok := any(new(T)).(I); !ok {
-		return fmt.Errorf("factory type %T does not implement I type %s", *new(T), reflect.TypeFor[I]())
+	var decider D
+	d.I, err = decider.Decide(*x)
+	if err != nil {
+		return decideError[I](codecCBOR, *x, err)
 	}
-	return Register[I, X](x, func() I {
-		return any(new(T)).(I)
-	})
+	return payloadError(codecCBOR, *x, d.I, cbor.Unmarshal(data, d.I))
 }
 
-// Register registers a factory function for interface I and discriminator X.
-// The factory must return a pointer type.
-func Register[I any, X comparable](x X, factory func() I) error {
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	t := factory()
-	if reflect.TypeOf(t).Kind() != reflect.Pointer {
-		return fmt.Errorf("factory must return a pointer type, got %T", t)
+// MarshalBSON marshals the contained value into a BSON document.
+// If the decider implements Discriminator and the discriminator marshals to a document,
+// its elements are merged into the emitted document.
+func (d Decodable[I, X, D]) MarshalBSON() ([]byte, error) {
+	data, err := bson.Marshal(d.I)
+	if err != nil {
+		return nil, err
 	}
 
-	key := typeKey[I, X]{x: x}
-	_, ok := registries[key]
-	if ok {
-		return fmt.Errorf("value %v already registered for registry[I: %s, X: %T]", x, reflect.TypeFor[I](), x)
+	x, ok := discriminate[I, X, D](d.I)
+	if !ok {
+		return data, nil
 	}
 
-	registries[key] = factory
-	return nil
+	t, tag, err := bson.MarshalValue(x)
+	if err != nil {
+		return nil, err
+	}
+	if t != bson.TypeEmbeddedDocument {
+		return data, nil
+	}
+	return mergeBSON(tag, data)
 }
 
-// RegistryDecider resolves a concrete type from a registry based on discriminator value.
-type RegistryDecider[I any, X comparable] struct {
+// MarshalBSONValue marshals the contained value as embedded document, or as null if it is nil.
+// It is used when the Decodable is a field of another document.
+func (d Decodable[I, X, D]) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if any(d.I) == nil {
+		return bson.TypeNull, nil, nil
+	}
+
+	data, err := d.MarshalBSON()
+	return bson.TypeEmbeddedDocument, data, err
 }
 
-// Decide returns a new instance of I from the registry for discriminator x.
-func (RegistryDecider[I, X]) Decide(x X) (I, error) {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	var i I
-	anyFactory, ok := registries[typeKey[I, X]{x: x}]
-	if !ok {
-		return i, fmt.Errorf("no factory found in registry[I: %s, X: %T] and X value %v", reflect.TypeFor[I](), x, x)
+// UnmarshalBSON does unmarshal the BSON document data into the contained value.
+// It uses the decider to resolve the concrete type based on the discriminator.
+// When X is a struct, bson decodes only the elements X holds and skips all others,
+// so the payload is fully decoded once, into the concrete type.
+func (d *Decodable[I, X, D]) UnmarshalBSON(data []byte) error {
+	x := new(X)
+	err := bson.Unmarshal(data, x)
+	if err != nil {
+		return discriminatorError[I, X](codecBSON, err)
 	}
 
-	factory, ok := anyFactory.(func() I)
-	if !ok {
-		return i, fmt.Errorf("registry[I: %s, X: %T] entry should be func() I but is: %T for X value %v", reflect.TypeFor[I](), x, anyFactory, x)
+	var decider D
+	d.I, err = decider.Decide(*x)
+	if err != nil {
+		return decideError[I](codecBSON, *x, err)
 	}
-
-	return factory(), nil
+	return payloadError(codecBSON, *x, d.I, bson.Unmarshal(data, d.I))
 }
 
-// FSelector is an interface for types that can provide a field name for discriminator lookup.
-type FSelector interface {
-	FieldName() string
-	~struct{}
+// UnmarshalBSONValue decodes an embedded document as described in UnmarshalBSON.
+// Null and undefined decode as nil.
+func (d *Decodable[I, X, D]) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bson.TypeNull, bson.TypeUndefined:
+		var i I
+		d.I = i
+		return nil
+	case bson.TypeEmbeddedDocument:
+		return d.UnmarshalBSON(data)
+	}
+	return discriminatorError[I, X](codecBSON, fmt.Errorf("cannot decode BSON %s into a document", t))
 }
 
-// RegisterF registers a factory function for interface I, discriminator X and field selector F.
-func RegisterF[I any, F FSelector, X comparable](x X, factory func() I) error {
-	mutex.Lock()
-	defer mutex.Unlock()
-
-	t := factory()
-	if reflect.TypeOf(t).Kind() != reflect.Pointer {
-		return fmt.Errorf("factory must return a pointer type, got %T", t)
+// MarshalXML encodes the contained value as element named by start.
+// If the decider implements Discriminator, the attributes and child elements
+// the discriminator encodes to are merged into the element.
+// Nothing is written if the contained value is nil.
+func (d Decodable[I, X, D]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if any(d.I) == nil {
+		return nil
 	}
 
-	key := typeKeyF[I, F, X]{x: x}
-	_, ok := registries[key]
-	if ok {
-		return fmt.Errorf("value %v already registered for registry[I: %s, F: %T, X: %T]", x, reflect.TypeFor[I](), *new(F), x)
+	value, ok, err := encodeXML(d.I, start)
+	if err != nil || !ok {
+		return err
 	}
 
-	registries[key] = factory
-	return nil
+	if x, ok := discriminate[I, X, D](d.I); ok {
+		tag, ok, err := encodeXML(x, start)
+		if err != nil {
+			return err
+		}
+		if ok {
+			value = mergeXML(tag, value)
+		}
+	}
+	return writeXML(e, value)
 }
 
-// typeKeyF is a unique key to get the registry for types I, X and F with a value of X
-type typeKeyF[I any, F FSelector, X comparable] struct {
-	x X
+// UnmarshalXML decodes the element opened by start into the contained value.
+// The element is read once, then the discriminator is decoded from it, typically from an attribute
+// or child element named by the xml struct tags of X, and the element is decoded into the concrete type.
+func (d *Decodable[I, X, D]) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	n, err := readXML(dec, start)
+	if err != nil {
+		return discriminatorError[I, X](codecXML, err)
+	}
+
+	x := new(X)
+	err = decodeXML(n, x)
+	if err != nil {
+		return discriminatorError[I, X](codecXML, err)
+	}
+
+	var decider D
+	d.I, err = decider.Decide(*x)
+	if err != nil {
+		return decideError[I](codecXML, *x, err)
+	}
+	return payloadError(codecXML, *x, d.I, decodeXML(n, d.I))
 }
 
-// DecodableF is a type alias for Decodable using FDecider.
-type DecodableF[I any, F FSelector, X comparable] = Decodable[I, map[string]X, FDecider[I, F, X]]
+// xAdapter adapts XDecider to Decider for generic use.
+type xAdapter[I any, X XDecider[I, X]] struct{}
 
-// FDecider resolves a concrete type from a registry based on a discriminator field in a map.
-type FDecider[I any, F FSelector, X comparable] struct{}
+func (xAdapter[I, X]) Decide(x X) (I, error) {
+	return x.Decide()
+}
 
-// Decide returns a new instance of I from the registry for the discriminator field in the map.
-func (FDecider[I, F, X]) Decide(mx map[string]X) (I, error) {
-	mutex.RLock()
-	defer mutex.RUnlock()
-	var i I
+// XDecider is an interface for types that can decide their own concrete type.
+type XDecider[I, X any] interface {
+	Decide() (I, error)
+	any
+}
 
-	fieldName := (*new(F)).FieldName()
-	x, ok := mx[fieldName]
-	if !ok {
-		return i, fmt.Errorf("discriminator field %s not found in map %v", fieldName, mx)
-	}
+// XDecodable is a type alias for Decodable using xAdapter.
+type XDecodable[I any, X XDecider[I, X]] = Decodable[I, X, xAdapter[I, X]]
 
-	anyFactory, ok := registries[typeKeyF[I, F, X]{x: x}]
-	if !ok {
-		return i, fmt.Errorf("no factory found in registry[I: %s, F: %T, X: %T] and X value %v", reflect.TypeFor[I](), *new(F), x, x)
-	}
+// RDecodable is a type alias for Decodable using RegistryDecider.
+type RDecodable[I any, X comparable] = Decodable[I, X, RegistryDecider[I, X]]
 
-	factory, ok := anyFactory.(func() I)
-	if !ok {
-		return i, fmt.Errorf("registry[I: %s, F: %T, X: %T] entry should be func() I but is: %T for X value %v", reflect.TypeFor[I](), *new(F), x, anyFactory, x)
-	}
+// SDecodable is a type alias for Decodable using ScopedDecider.
+type SDecodable[I any, X comparable, S Scope] = Decodable[I, X, ScopedDecider[I, X, S]]
 
-	return factory(), nil
+// FSelector is an interface for types that can provide a field name for discriminator lookup.
+// The field name is a top-level key, which may contain ".", or a JSON Pointer starting with "/"
+// ("/header/type") into nested objects.
+// For XML, keys are local names of child elements and a last key starting with "@" names an attribute.
+type FSelector interface {
+	FieldName() string
+	~struct{}
 }
+
+// DecodableF is a type alias for Decodable using FDecider.
+// Only the field named by F is decoded as discriminator, the other fields may have any type.
+type DecodableF[I any, F FSelector, X comparable] = Decodable[I, FValue[F, X], FDecider[I, F, X]]
+
+// SDecodableF is a type alias for Decodable using ScopedFDecider.
+type SDecodableF[I any, F FSelector, X comparable, S Scope] = Decodable[I, FValue[F, X], ScopedFDecider[I, F, X, S]]
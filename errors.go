@@ -0,0 +1,290 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Sentinel errors matched by the structured errors of this package with errors.Is.
+var (
+	// ErrNotRegistered is matched by NotRegisteredError.
+	ErrNotRegistered = errors.New("ijson: not registered")
+	// ErrDuplicate is matched by DuplicateError.
+	ErrDuplicate = errors.New("ijson: already registered")
+	// ErrPointerType is matched by a FactoryError for a type parameter T that is a pointer.
+	ErrPointerType = errors.New("ijson: factory type must not be a pointer")
+	// ErrNotImplemented is matched by a FactoryError for a type that does not implement I.
+	ErrNotImplemented = errors.New("ijson: factory type does not implement I")
+	// ErrNonPointerFactory is matched by a FactoryError for a factory that does not return a pointer.
+	ErrNonPointerFactory = errors.New("ijson: factory must return a pointer type")
+	// ErrTupleType is matched by a FactoryError for a tuple type that does not fit the field names of an MSelector.
+	ErrTupleType = errors.New("ijson: tuple type does not match the field names")
+	// ErrMissingDiscriminator is matched by MissingFieldError.
+	ErrMissingDiscriminator = errors.New("ijson: discriminator field not found")
+	// ErrNoDiscriminator is matched by NoDiscriminatorError.
+	ErrNoDiscriminator = errors.New("ijson: no discriminator found")
+	// ErrFrozen is returned when changing a frozen registry.
+	ErrFrozen = errors.New("ijson: registry is frozen")
+	// ErrPayloadDecode is matched by a DecodeError in PhasePayload.
+	ErrPayloadDecode = errors.New("ijson: payload decode failed")
+)
+
+// registryName describes the registry for the interface type i, field selector f and discriminator type x.
+// f is nil if no field selector is used.
+func registryName(i, f, x reflect.Type) string {
+	if f == nil {
+		return fmt.Sprintf("registry[I: %s, X: %s]", i, x)
+	}
+	return fmt.Sprintf("registry[I: %s, F: %s, X: %s]", i, f, x)
+}
+
+// NotRegisteredError is returned by a decider if no factory is registered for a discriminator value.
+type NotRegisteredError struct {
+	Interface     reflect.Type // The interface type I
+	Selector      reflect.Type // The field selector F or M, nil if none is used
+	Discriminator reflect.Type // The discriminator type X
+	Value         any          // The discriminator value
+}
+
+func (e *NotRegisteredError) Error() string {
+	return fmt.Sprintf("no factory found in %s and X value %v", registryName(e.Interface, e.Selector, e.Discriminator), e.Value)
+}
+
+// Is reports whether target is ErrNotRegistered.
+func (e *NotRegisteredError) Is(target error) bool {
+	return target == ErrNotRegistered
+}
+
+// DuplicateError is returned if a registration already exists.
+type DuplicateError struct {
+	Interface     reflect.Type // The interface type I
+	Selector      reflect.Type // The field selector F or M, nil if none is used
+	Discriminator reflect.Type // The discriminator type X, nil for untagged candidates
+	Value         any          // The discriminator value, nil for fallbacks and untagged candidates
+	Default       bool         // Whether a fallback factory was registered twice
+	Type          reflect.Type // The concrete type of an untagged candidate
+}
+
+func (e *DuplicateError) Error() string {
+	switch {
+	case e.Discriminator == nil:
+		return fmt.Sprintf("type %s already registered as untagged candidate for I type %s", e.Type, e.Interface)
+	case e.Default:
+		return fmt.Sprintf("default already registered for %s", registryName(e.Interface, e.Selector, e.Discriminator))
+	}
+	return fmt.Sprintf("value %v already registered for %s", e.Value, registryName(e.Interface, e.Selector, e.Discriminator))
+}
+
+// Is reports whether target is ErrDuplicate.
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrDuplicate
+}
+
+// FactoryError is returned if a type or factory cannot be registered for an interface.
+// Err is one of ErrPointerType, ErrNotImplemented, ErrNonPointerFactory and ErrTupleType.
+type FactoryError struct {
+	Err       error        // The sentinel describing the failure
+	Interface reflect.Type // The interface type I
+	Type      reflect.Type // The type registered or returned by the factory, or the tuple type X
+}
+
+func (e *FactoryError) Error() string {
+	switch e.Err {
+	case ErrPointerType:
+		return fmt.Sprintf("factory type %s must not be a pointer", e.Type)
+	case ErrNotImplemented:
+		return fmt.Sprintf("factory type %s does not implement I type %s", e.Type, e.Interface)
+	case ErrTupleType:
+		return fmt.Sprintf("tuple type %s must be an array or struct of exported fields with one element per field name", e.Type)
+	}
+	return fmt.Sprintf("factory must return a pointer type, got %v", e.Type)
+}
+
+// Unwrap returns the sentinel describing the failure.
+func (e *FactoryError) Unwrap() error {
+	return e.Err
+}
+
+// checkFactory returns a FactoryError if t, as returned by a factory for I, is not a pointer.
+func checkFactory[I any](t any) error {
+	if typ := reflect.TypeOf(t); typ == nil || typ.Kind() != reflect.Pointer {
+		return &FactoryError{Err: ErrNonPointerFactory, Interface: reflect.TypeFor[I](), Type: typ}
+	}
+	return nil
+}
+
+// MissingFieldError is returned if the field holding the discriminator is missing.
+type MissingFieldError struct {
+	Interface reflect.Type // The interface type I
+	Selector  reflect.Type // The field selector F or M or the envelope E naming the field
+	Field     string       // The name of the missing field
+}
+
+func (e *MissingFieldError) Error() string {
+	return fmt.Sprintf("discriminator field %s not found", e.Field)
+}
+
+// Is reports whether target is ErrMissingDiscriminator.
+func (e *MissingFieldError) Is(target error) bool {
+	return target == ErrMissingDiscriminator
+}
+
+// NoDiscriminatorError is returned when marshaling a value into a format that requires its discriminator,
+// like AdjacentDecodable or ExternalDecodable, if none is registered for its concrete type and it does not implement Tagged.
+type NoDiscriminatorError struct {
+	Interface     reflect.Type // The interface type I
+	Discriminator reflect.Type // The discriminator type X
+	Type          reflect.Type // The concrete type of the value
+}
+
+func (e *NoDiscriminatorError) Error() string {
+	return fmt.Sprintf("no discriminator found for type %v", e.Type)
+}
+
+// Is reports whether target is ErrNoDiscriminator.
+func (e *NoDiscriminatorError) Is(target error) bool {
+	return target == ErrNoDiscriminator
+}
+
+// Codecs reported by DecodeError.
+const (
+	codecJSON    = "json"
+	codecMsgpack = "msgpack"
+	codecYAML    = "yaml"
+	codecCBOR    = "cbor"
+	codecBSON    = "bson"
+	codecXML     = "xml"
+)
+
+// Phase is the step of decoding a Decodable that failed.
+type Phase int
+
+const (
+	// PhaseDiscriminator is the extraction of the discriminator from the payload.
+	PhaseDiscriminator Phase = iota + 1
+	// PhaseDecide is the decision on the concrete type by the decider.
+	PhaseDecide
+	// PhasePayload is the decoding of the payload into the concrete type.
+	PhasePayload
+	// PhaseContainer is the splitting of the array of a DecodableSlice or the map of a DecodableMap into elements.
+	PhaseContainer
+)
+
+// String returns the name of the phase.
+func (p Phase) String() string {
+	switch p {
+	case PhaseDiscriminator:
+		return "discriminator"
+	case PhaseDecide:
+		return "decide"
+	case PhasePayload:
+		return "payload"
+	case PhaseContainer:
+		return "container"
+	}
+	return fmt.Sprintf("Phase(%d)", int(p))
+}
+
+// DecodeError is returned if decoding a Decodable, AdjacentDecodable, ExternalDecodable, YAMLTagDecodable
+// or CBORTagDecodable fails. DecodableSlice and DecodableMap return it in PhaseContainer for input that is not
+// an array or map, and wrap it in ElementError or ElementsError for elements that fail to decode.
+// It matches ErrPayloadDecode with errors.Is if the payload could not be decoded into the concrete type.
+type DecodeError struct {
+	Phase         Phase        // The phase that failed
+	Codec         string       // The codec, "json", "msgpack", "yaml", "cbor", "bson" or "xml"
+	Interface     reflect.Type // The interface type I
+	Discriminator reflect.Type // The discriminator type X
+	Value         any          // The discriminator value, nil in PhaseDiscriminator and PhaseContainer
+	Type          reflect.Type // The concrete type chosen by the decider, nil before PhasePayload
+	Err           error        // The error of the phase
+}
+
+func (e *DecodeError) Error() string {
+	switch e.Phase {
+	case PhaseDiscriminator:
+		return fmt.Sprintf("%s: decode discriminator %s: %v", e.Codec, e.Discriminator, e.Err)
+	case PhaseDecide:
+		// a NotRegisteredError already names the value
+		if _, ok := e.Err.(*NotRegisteredError); ok {
+			return fmt.Sprintf("%s: decide %s: %v", e.Codec, e.Interface, e.Err)
+		}
+		return fmt.Sprintf("%s: decide %s for X value %v: %v", e.Codec, e.Interface, e.Value, e.Err)
+	case PhaseContainer:
+		return fmt.Sprintf("%s: decode elements of %s: %v", e.Codec, e.Interface, e.Err)
+	}
+	return fmt.Sprintf("%s: decode %v for X value %v: %v", e.Codec, e.Type, e.Value, e.Err)
+}
+
+// Is reports whether target is ErrPayloadDecode and the payload could not be decoded.
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrPayloadDecode && e.Phase == PhasePayload
+}
+
+// Unwrap returns the error of the phase.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// discriminatorError wraps a non-nil err of extracting the discriminator as DecodeError.
+func discriminatorError[I, X any](codec string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &DecodeError{
+		Phase:         PhaseDiscriminator,
+		Codec:         codec,
+		Interface:     reflect.TypeFor[I](),
+		Discriminator: reflect.TypeFor[X](),
+		Err:           err,
+	}
+}
+
+// containerError wraps a non-nil err of splitting a container into its elements as DecodeError.
+func containerError[I, X any](codec string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &DecodeError{
+		Phase:         PhaseContainer,
+		Codec:         codec,
+		Interface:     reflect.TypeFor[I](),
+		Discriminator: reflect.TypeFor[X](),
+		Err:           err,
+	}
+}
+
+// decideError wraps a non-nil err of deciding the concrete type for x as DecodeError.
+func decideError[I, X any](codec string, x X, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &DecodeError{
+		Phase:         PhaseDecide,
+		Codec:         codec,
+		Interface:     reflect.TypeFor[I](),
+		Discriminator: reflect.TypeFor[X](),
+		Value:         x,
+		Err:           err,
+	}
+}
+
+// payloadError wraps a non-nil err of decoding the payload into i as DecodeError.
+func payloadError[I, X any](codec string, x X, i I, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &DecodeError{
+		Phase:         PhasePayload,
+		Codec:         codec,
+		Interface:     reflect.TypeFor[I](),
+		Discriminator: reflect.TypeFor[X](),
+		Value:         x,
+		Type:          reflect.TypeOf(i),
+		Err:           err,
+	}
+}
@@ -1,12 +1,16 @@
 package ijson_test
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 
 	"github.com/Nikkolix/ijson"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 type TestInterface interface {
@@ -230,3 +234,112 @@ func TestErrorsWithDifferentTypes(t *testing.T) {
 	require.Error(t, err)
 	assert.Equal(t, "no factory found in registry[I: ijson_test.AnotherInterface, X: ijson_test.IntDiscriminator] and X value 1", err.Error())
 }
+
+func TestErrors_IsAs(t *testing.T) {
+	ijson.ResetRegistries()
+
+	err := ijson.RegisterT[*ValidTestStruct, TestInterface, TestDiscriminator](TestTypeA)
+	var factoryErr *ijson.FactoryError
+	require.ErrorAs(t, err, &factoryErr)
+	assert.ErrorIs(t, err, ijson.ErrPointerType)
+	assert.Equal(t, reflect.TypeFor[TestInterface](), factoryErr.Interface)
+	assert.Equal(t, reflect.TypeFor[*ValidTestStruct](), factoryErr.Type)
+
+	err = ijson.RegisterT[InvalidTestStruct, TestInterface, TestDiscriminator](TestTypeA)
+	assert.ErrorIs(t, err, ijson.ErrNotImplemented)
+
+	err = ijson.Register[any, TestDiscriminator](TestTypeA, func() any { return nil })
+	assert.ErrorIs(t, err, ijson.ErrNonPointerFactory)
+	assert.EqualError(t, err, "factory must return a pointer type, got <nil>")
+
+	require.NoError(t, ijson.RegisterT[ValidTestStruct, TestInterface](TestTypeA))
+	err = ijson.RegisterT[ValidTestStruct, TestInterface](TestTypeA)
+	var duplicate *ijson.DuplicateError
+	require.ErrorAs(t, err, &duplicate)
+	assert.ErrorIs(t, err, ijson.ErrDuplicate)
+	assert.Equal(t, reflect.TypeFor[TestDiscriminator](), duplicate.Discriminator)
+	assert.Equal(t, TestTypeA, duplicate.Value)
+	assert.Nil(t, duplicate.Selector)
+
+	_, err = ijson.RegistryDecider[TestInterface, TestDiscriminator]{}.Decide(TestTypeB)
+	var notRegistered *ijson.NotRegisteredError
+	require.ErrorAs(t, err, &notRegistered)
+	assert.ErrorIs(t, err, ijson.ErrNotRegistered)
+	assert.NotErrorIs(t, err, ijson.ErrDuplicate)
+	assert.Equal(t, reflect.TypeFor[TestInterface](), notRegistered.Interface)
+	assert.Equal(t, TestTypeB, notRegistered.Value)
+
+	_, err = ijson.FDecider[TestInterface, TestFSelector, string]{}.Decide(ijson.FValue[TestFSelector, string]{})
+	var missing *ijson.MissingFieldError
+	require.ErrorAs(t, err, &missing)
+	assert.ErrorIs(t, err, ijson.ErrMissingDiscriminator)
+	assert.Equal(t, "type", missing.Field)
+	assert.Equal(t, reflect.TypeFor[TestFSelector](), missing.Selector)
+
+	require.NoError(t, ijson.RegisterT[PersonStruct, UnmarshalTestInterface](UnmarshalDiscriminator{Type: PersonType}))
+	var d ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]
+	err = json.Unmarshal([]byte(`{"type":"person","age":"old"}`), &d)
+	var decodeErr *ijson.DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	assert.ErrorIs(t, err, ijson.ErrPayloadDecode)
+	assert.Equal(t, ijson.PhasePayload, decodeErr.Phase)
+	assert.Equal(t, reflect.TypeFor[*PersonStruct](), decodeErr.Type)
+	assert.Equal(t, UnmarshalDiscriminator{Type: PersonType}, decodeErr.Value)
+	var typeErr *json.UnmarshalTypeError
+	assert.ErrorAs(t, err, &typeErr)
+}
+
+func TestDecodeError_Phases(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterT[PersonStruct, UnmarshalTestInterface](UnmarshalDiscriminator{Type: PersonType}))
+
+	encode := func(v any) []byte {
+		data, err := msgpack.Marshal(v)
+		require.NoError(t, err)
+		return data
+	}
+
+	tests := []struct {
+		name  string
+		codec string
+		data  []byte
+		phase ijson.Phase
+		value any
+		typ   reflect.Type
+	}{
+		{name: "json discriminator", codec: "json", data: []byte(`{"type":1}`), phase: ijson.PhaseDiscriminator},
+		{name: "json decide", codec: "json", data: []byte(`{"type":"robot"}`), phase: ijson.PhaseDecide, value: UnmarshalDiscriminator{Type: "robot"}},
+		{name: "json payload", codec: "json", data: []byte(`{"type":"person","age":"x"}`), phase: ijson.PhasePayload, value: UnmarshalDiscriminator{Type: PersonType}, typ: reflect.TypeFor[*PersonStruct]()},
+		{name: "msgpack discriminator", codec: "msgpack", data: encode(map[string]int{"type": 1}), phase: ijson.PhaseDiscriminator},
+		{name: "msgpack decide", codec: "msgpack", data: encode(map[string]string{"type": "robot"}), phase: ijson.PhaseDecide, value: UnmarshalDiscriminator{Type: "robot"}},
+		{name: "msgpack payload", codec: "msgpack", data: encode(map[string]string{"type": "person", "age": "x"}), phase: ijson.PhasePayload, value: UnmarshalDiscriminator{Type: PersonType}, typ: reflect.TypeFor[*PersonStruct]()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]
+			var err error
+			if tt.codec == "json" {
+				err = d.UnmarshalJSON(tt.data)
+			} else {
+				err = d.UnmarshalMsgpack(tt.data)
+			}
+
+			var decodeErr *ijson.DecodeError
+			require.ErrorAs(t, err, &decodeErr)
+			assert.Equal(t, tt.phase, decodeErr.Phase)
+			assert.Equal(t, tt.codec, decodeErr.Codec)
+			assert.Equal(t, reflect.TypeFor[UnmarshalTestInterface](), decodeErr.Interface)
+			assert.Equal(t, reflect.TypeFor[UnmarshalDiscriminator](), decodeErr.Discriminator)
+			assert.Equal(t, tt.value, decodeErr.Value)
+			assert.Equal(t, tt.typ, decodeErr.Type)
+			assert.Equal(t, tt.phase == ijson.PhasePayload, errors.Is(err, ijson.ErrPayloadDecode))
+			assert.Equal(t, tt.phase == ijson.PhaseDecide, errors.Is(err, ijson.ErrNotRegistered))
+		})
+	}
+
+	assert.Equal(t, "discriminator", ijson.PhaseDiscriminator.String())
+	assert.Equal(t, "decide", ijson.PhaseDecide.String())
+	assert.Equal(t, "payload", ijson.PhasePayload.String())
+	assert.Equal(t, "container", ijson.PhaseContainer.String())
+	assert.Equal(t, "Phase(0)", ijson.Phase(0).String())
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var (
+	_ json.Marshaler   = ExternalDecodable[string, string, RegistryDecider[string, string]]{}
+	_ json.Unmarshaler = &ExternalDecodable[string, string, RegistryDecider[string, string]]{}
+
+	_ msgpack.Marshaler     = ExternalDecodable[string, string, RegistryDecider[string, string]]{}
+	_ msgpack.Unmarshaler   = &ExternalDecodable[string, string, RegistryDecider[string, string]]{}
+	_ msgpack.CustomEncoder = ExternalDecodable[string, string, RegistryDecider[string, string]]{}
+	_ msgpack.CustomDecoder = &ExternalDecodable[string, string, RegistryDecider[string, string]]{}
+)
+
+// ExternalDecodable is a generic wrapper for polymorphic (de)serialization of externally tagged objects,
+// where the single key of the wrapping object is the discriminator: {"dog": {...}}.
+// I is the interface type, X is the discriminator type and D is the decider.
+// X must be usable as a map key by the codec, e.g. a string or integer kind or an encoding.TextUnmarshaler.
+// Marshaling requires D to implement Discriminator.
+type ExternalDecodable[I any, X comparable, D Decider[I, X]] struct {
+	I I // The decoded value implementing I
+}
+
+// RExternalDecodable is a type alias for ExternalDecodable using RegistryDecider.
+type RExternalDecodable[I any, X comparable] = ExternalDecodable[I, X, RegistryDecider[I, X]]
+
+// MarshalJSON marshals the contained value into an object keyed by its discriminator using JSON.
+func (d ExternalDecodable[I, X, D]) MarshalJSON() ([]byte, error) {
+	if any(d.I) == nil {
+		return []byte("null"), nil
+	}
+
+	x, err := mustDiscriminate[I, X, D](d.I)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := json.Marshal(d.I)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(map[X]json.RawMessage{x: content})
+}
+
+// UnmarshalJSON does unmarshal the object in data into the contained value using JSON.
+// The object must have exactly one key, which is decoded into X.
+func (d *ExternalDecodable[I, X, D]) UnmarshalJSON(data []byte) error {
+	var m map[X]json.RawMessage
+	err := json.Unmarshal(data, &m)
+	if err != nil {
+		return discriminatorError[I, X](codecJSON, err)
+	}
+	if m == nil {
+		var i I
+		d.I = i
+		return nil
+	}
+
+	x, content, err := externalEntry(m)
+	if err != nil {
+		return discriminatorError[I, X](codecJSON, err)
+	}
+
+	var decider D
+	d.I, err = decider.Decide(x)
+	if err != nil {
+		return decideError[I](codecJSON, x, err)
+	}
+	return payloadError(codecJSON, x, d.I, json.Unmarshal(content, d.I))
+}
+
+// MarshalMsgpack marshals the contained value into a map keyed by its discriminator using msgpack.
+func (d ExternalDecodable[I, X, D]) MarshalMsgpack() ([]byte, error) {
+	if any(d.I) == nil {
+		return msgpack.Marshal(nil)
+	}
+
+	x, err := mustDiscriminate[I, X, D](d.I)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := msgpack.Marshal(d.I)
+	if err != nil {
+		return nil, err
+	}
+	return msgpack.Marshal(map[X]msgpack.RawMessage{x: content})
+}
+
+// EncodeMsgpack encodes the contained value into a map keyed by its discriminator to the msgpack encoder.
+func (d ExternalDecodable[I, X, D]) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if any(d.I) == nil {
+		return enc.EncodeNil()
+	}
+
+	x, err := mustDiscriminate[I, X, D](d.I)
+	if err != nil {
+		return err
+	}
+
+	if err := enc.EncodeMapLen(1); err != nil {
+		return err
+	}
+	return enc.EncodeMulti(x, d.I)
+}
+
+// DecodeMsgpack decodes the next map of the msgpack decoder into the contained value.
+func (d *ExternalDecodable[I, X, D]) DecodeMsgpack(dec *msgpack.Decoder) error {
+	data, err := dec.DecodeRaw()
+	if err != nil {
+		return discriminatorError[I, X](codecMsgpack, err)
+	}
+	return d.UnmarshalMsgpack(data)
+}
+
+// UnmarshalMsgpack does unmarshal the map in data into the contained value using msgpack.
+// The map must have exactly one key, which is decoded into X.
+func (d *ExternalDecodable[I, X, D]) UnmarshalMsgpack(data []byte) error {
+	var m map[X]msgpack.RawMessage
+	err := msgpack.Unmarshal(data, &m)
+	if err != nil {
+		return discriminatorError[I, X](codecMsgpack, err)
+	}
+	if m == nil {
+		var i I
+		d.I = i
+		return nil
+	}
+
+	x, content, err := externalEntry(m)
+	if err != nil {
+		return discriminatorError[I, X](codecMsgpack, err)
+	}
+
+	var decider D
+	d.I, err = decider.Decide(x)
+	if err != nil {
+		return decideError[I](codecMsgpack, x, err)
+	}
+	return payloadError(codecMsgpack, x, d.I, msgpack.Unmarshal(content, d.I))
+}
+
+// externalEntry returns the single entry of an externally tagged object.
+func externalEntry[X comparable, V any](m map[X]V) (X, V, error) {
+	var x X
+	var v V
+	if len(m) != 1 {
+		return x, v, fmt.Errorf("externally tagged object must have exactly one key, got %d", len(m))
+	}
+
+	for x, v = range m {
+	}
+	return x, v, nil
+}
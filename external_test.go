@@ -0,0 +1,154 @@
+package ijson_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/Nikkolix/ijson"
+)
+
+func TestExternalDecodable_UnmarshalJSON(t *testing.T) {
+	registerShapes(t)
+
+	var d ijson.RExternalDecodable[Shape, string]
+	err := json.Unmarshal([]byte(`{"circle":{"radius":2}}`), &d)
+	require.NoError(t, err)
+	assert.Equal(t, &Circle{Radius: 2}, d.I)
+}
+
+func TestExternalDecodable_JSON_RoundTrip(t *testing.T) {
+	registerShapes(t)
+
+	in := []ijson.RExternalDecodable[Shape, string]{{I: &Circle{Radius: 1}}, {I: &Square{Side: 2}}, {}}
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"circle":{"radius":1}},{"square":{"side":2}},null]`, string(data))
+
+	var out []ijson.RExternalDecodable[Shape, string]
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+}
+
+type ShapeCode int
+
+func TestExternalDecodable_IntegerKeys(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterT[Circle, Shape](ShapeCode(1)))
+
+	in := ijson.RExternalDecodable[Shape, ShapeCode]{I: &Circle{Radius: 3}}
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.Equal(t, `{"1":{"radius":3}}`, string(data))
+
+	var out ijson.RExternalDecodable[Shape, ShapeCode]
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+
+	packed, err := msgpack.Marshal(in)
+	require.NoError(t, err)
+
+	out = ijson.RExternalDecodable[Shape, ShapeCode]{}
+	require.NoError(t, msgpack.Unmarshal(packed, &out))
+	assert.Equal(t, in, out)
+}
+
+func TestExternalDecodable_UnmarshalJSON_Errors(t *testing.T) {
+	registerShapes(t)
+
+	tests := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{name: "no key", data: `{}`, err: "json: decode discriminator string: externally tagged object must have exactly one key, got 0"},
+		{name: "two keys", data: `{"circle":{},"square":{}}`, err: "json: decode discriminator string: externally tagged object must have exactly one key, got 2"},
+		{name: "unknown key", data: `{"hexagon":{}}`, err: "json: decide ijson_test.Shape: no factory found in registry[I: ijson_test.Shape, X: string] and X value hexagon"},
+		{name: "invalid content", data: `{"circle":[]}`, err: "json: decode *ijson_test.Circle for X value circle: json: cannot unmarshal array into Go value of type ijson_test.Circle"},
+		{name: "invalid json", data: `{"circle":}`, err: "json: decode discriminator string: invalid character '}' looking for beginning of value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d ijson.RExternalDecodable[Shape, string]
+			err := d.UnmarshalJSON([]byte(tt.data))
+			require.Error(t, err)
+			assert.Equal(t, tt.err, err.Error())
+		})
+	}
+}
+
+func TestExternalDecodable_Marshal_Unregistered(t *testing.T) {
+	ijson.ResetRegistries()
+
+	d := ijson.RExternalDecodable[Shape, string]{I: &Circle{}}
+
+	_, err := d.MarshalJSON()
+	require.Error(t, err)
+	assert.Equal(t, "no discriminator found for type *ijson_test.Circle", err.Error())
+
+	_, err = d.MarshalMsgpack()
+	require.Error(t, err)
+	assert.Equal(t, "no discriminator found for type *ijson_test.Circle", err.Error())
+	assert.ErrorIs(t, err, ijson.ErrNoDiscriminator)
+}
+
+func TestExternalDecodable_Msgpack_RoundTrip(t *testing.T) {
+	registerShapes(t)
+
+	type Drawing struct {
+		Shapes []ijson.RExternalDecodable[Shape, string] `msgpack:"shapes"`
+	}
+
+	in := Drawing{Shapes: []ijson.RExternalDecodable[Shape, string]{{I: &Circle{Radius: 1}}, {I: &Square{Side: 2}}, {}}}
+	data, err := msgpack.Marshal(in)
+	require.NoError(t, err)
+
+	var raw map[string][]map[string]map[string]float64
+	require.NoError(t, msgpack.Unmarshal(data, &raw))
+	assert.Equal(t, map[string]float64{"radius": 1}, raw["shapes"][0]["circle"])
+
+	var out Drawing
+	require.NoError(t, msgpack.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+}
+
+func TestExternalDecodable_UnmarshalMsgpack_Errors(t *testing.T) {
+	registerShapes(t)
+
+	encode := func(v any) []byte {
+		data, err := msgpack.Marshal(v)
+		require.NoError(t, err)
+		return data
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{name: "two keys", data: encode(map[string]any{"circle": nil, "square": nil}), err: "msgpack: decode discriminator string: externally tagged object must have exactly one key, got 2"},
+		{name: "unknown key", data: encode(map[string]any{"hexagon": nil}), err: "msgpack: decide ijson_test.Shape: no factory found in registry[I: ijson_test.Shape, X: string] and X value hexagon"},
+		{name: "invalid content", data: encode(map[string]any{"circle": "x"}), err: "msgpack: decode *ijson_test.Circle for X value circle: msgpack: unexpected code=a1 decoding map length"},
+		{name: "not a map", data: encode([]int{}), err: "msgpack: decode discriminator string: msgpack: unexpected code=90 decoding map length"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d ijson.RExternalDecodable[Shape, string]
+			err := d.UnmarshalMsgpack(tt.data)
+			require.Error(t, err)
+			assert.Equal(t, tt.err, err.Error())
+		})
+	}
+
+	err := (&ijson.RExternalDecodable[Shape, string]{}).DecodeMsgpack(msgpack.NewDecoder(&emptyReader{}))
+	require.Error(t, err)
+}
+
+type emptyReader struct{}
+
+func (*emptyReader) Read([]byte) (int, error) { return 0, assert.AnError }
@@ -0,0 +1,435 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	_ json.Marshaler      = FValue[fieldType, any]{}
+	_ json.Unmarshaler    = &FValue[fieldType, any]{}
+	_ msgpack.Marshaler   = FValue[fieldType, any]{}
+	_ msgpack.Unmarshaler = &FValue[fieldType, any]{}
+	_ yaml.Marshaler      = FValue[fieldType, any]{}
+	_ yaml.Unmarshaler    = &FValue[fieldType, any]{}
+	_ cbor.Marshaler      = FValue[fieldType, any]{}
+	_ cbor.Unmarshaler    = &FValue[fieldType, any]{}
+	_ bson.Marshaler      = FValue[fieldType, any]{}
+	_ bson.Unmarshaler    = &FValue[fieldType, any]{}
+	_ xml.Marshaler       = FValue[fieldType, any]{}
+	_ xml.Unmarshaler     = &FValue[fieldType, any]{}
+)
+
+// FValue is the discriminator of a DecodableF: the value of the field named by F.
+// Decoding an object into it extracts only that field and skips all other fields without decoding them,
+// so the other fields may have any type.
+// The field name may be a JSON Pointer into nested objects ("/header/type").
+type FValue[F FSelector, X any] struct {
+	X     X    // The value of the field
+	Found bool // Whether the field is present
+}
+
+// String returns the field name and value, as used in error messages.
+func (v FValue[F, X]) String() string {
+	if !v.Found {
+		return (*new(F)).FieldName() + "=<missing>"
+	}
+	return fmt.Sprintf("%s=%v", (*new(F)).FieldName(), v.X)
+}
+
+// MarshalJSON marshals the field as a JSON object holding the value at the path of F,
+// or an empty object if the field is not present.
+func (v FValue[F, X]) MarshalJSON() ([]byte, error) {
+	if !v.Found {
+		return []byte("{}"), nil
+	}
+
+	data, err := json.Marshal(v.X)
+	if err != nil {
+		return nil, err
+	}
+	return nestJSON(data, fieldPath((*new(F)).FieldName()))
+}
+
+// UnmarshalJSON decodes the field at the path of F from the JSON object in data.
+// Keys are matched exactly. A null value, a missing key or a non-object on the path decodes as not present.
+func (v *FValue[F, X]) UnmarshalJSON(data []byte) error {
+	*v = FValue[F, X]{}
+	if isJSONNull(data) {
+		return nil
+	}
+
+	value, ok, err := lookupJSON(data, fieldPath((*new(F)).FieldName()))
+	if err != nil || !ok {
+		return err
+	}
+	if err := json.Unmarshal(value, &v.X); err != nil {
+		return err
+	}
+	v.Found = true
+	return nil
+}
+
+// MarshalMsgpack marshals the field as a msgpack map holding the value at the path of F,
+// or an empty map if the field is not present.
+func (v FValue[F, X]) MarshalMsgpack() ([]byte, error) {
+	if !v.Found {
+		return encodeMsgpackMap(nil)
+	}
+
+	data, err := msgpack.Marshal(v.X)
+	if err != nil {
+		return nil, err
+	}
+	return nestMsgpack(data, fieldPath((*new(F)).FieldName()))
+}
+
+// UnmarshalMsgpack decodes the field at the path of F from the msgpack map in data.
+// A nil value, a missing key or a non-map on the path decodes as not present.
+func (v *FValue[F, X]) UnmarshalMsgpack(data []byte) error {
+	*v = FValue[F, X]{}
+	if isMsgpackNil(data) {
+		return nil
+	}
+
+	value, ok, err := lookupMsgpack(data, fieldPath((*new(F)).FieldName()))
+	if err != nil || !ok {
+		return err
+	}
+	if err := msgpack.Unmarshal(value, &v.X); err != nil {
+		return err
+	}
+	v.Found = true
+	return nil
+}
+
+// MarshalYAML marshals the field as a YAML mapping holding the value at the path of F,
+// or an empty mapping if the field is not present.
+func (v FValue[F, X]) MarshalYAML() (any, error) {
+	if !v.Found {
+		return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}, nil
+	}
+
+	var value yaml.Node
+	if err := value.Encode(v.X); err != nil {
+		return nil, err
+	}
+	return nestYAML(&value, fieldPath((*new(F)).FieldName())), nil
+}
+
+// UnmarshalYAML decodes the field at the path of F from the YAML mapping node.
+// A null value, a missing key or a non-mapping on the path decodes as not present.
+func (v *FValue[F, X]) UnmarshalYAML(node *yaml.Node) error {
+	*v = FValue[F, X]{}
+	if isYAMLNull(node) {
+		return nil
+	}
+
+	value, ok, err := lookupYAML(node, fieldPath((*new(F)).FieldName()))
+	if err != nil || !ok {
+		return err
+	}
+	if err := value.Decode(&v.X); err != nil {
+		return err
+	}
+	v.Found = true
+	return nil
+}
+
+// MarshalCBOR marshals the field as a CBOR map holding the value at the path of F,
+// or an empty map if the field is not present.
+func (v FValue[F, X]) MarshalCBOR() ([]byte, error) {
+	if !v.Found {
+		return encodeCBORMap(nil), nil
+	}
+
+	data, err := cbor.Marshal(v.X)
+	if err != nil {
+		return nil, err
+	}
+	return nestCBOR(data, fieldPath((*new(F)).FieldName())), nil
+}
+
+// UnmarshalCBOR decodes the field at the path of F from the CBOR map in data.
+// Only text keys are matched. A null or undefined value, a missing key or a non-map on the path
+// decodes as not present.
+func (v *FValue[F, X]) UnmarshalCBOR(data []byte) error {
+	*v = FValue[F, X]{}
+	if isCBORNull(data) {
+		return nil
+	}
+
+	value, ok, err := lookupCBOR(data, fieldPath((*new(F)).FieldName()))
+	if err != nil || !ok {
+		return err
+	}
+	if err := cbor.Unmarshal(value, &v.X); err != nil {
+		return err
+	}
+	v.Found = true
+	return nil
+}
+
+// MarshalBSON marshals the field as a BSON document holding the value at the path of F,
+// or an empty document if the field is not present.
+func (v FValue[F, X]) MarshalBSON() ([]byte, error) {
+	if !v.Found {
+		return bson.Marshal(bson.D{})
+	}
+
+	t, data, err := bson.MarshalValue(v.X)
+	if err != nil {
+		return nil, err
+	}
+	return nestBSON(bson.RawValue{Type: t, Value: data}, fieldPath((*new(F)).FieldName()))
+}
+
+// UnmarshalBSON decodes the field at the path of F from the BSON document in data.
+// A missing key or a non-document on the path decodes as not present.
+func (v *FValue[F, X]) UnmarshalBSON(data []byte) error {
+	*v = FValue[F, X]{}
+	value, ok, err := lookupBSON(data, fieldPath((*new(F)).FieldName()))
+	if err != nil || !ok {
+		return err
+	}
+	if err := value.Unmarshal(&v.X); err != nil {
+		return err
+	}
+	v.Found = true
+	return nil
+}
+
+// MarshalXML encodes the field as element named by start holding the value at the path of F,
+// or an empty element if the field is not present.
+// A last key of the path starting with "@" names an attribute, so "@type" encodes as type="...".
+func (v FValue[F, X]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if !v.Found {
+		return writeXML(e, &xmlNode{start: start})
+	}
+
+	n, err := nestXML(start, fieldPath((*new(F)).FieldName()), v.X)
+	if err != nil {
+		return err
+	}
+	return writeXML(e, n)
+}
+
+// UnmarshalXML decodes the field at the path of F from the element opened by start.
+// Keys match the local names of child elements, so namespaces are ignored,
+// and a last key starting with "@" matches an attribute, like "@type" for xsi:type="circle".
+// A missing element or attribute decodes as not present.
+func (v *FValue[F, X]) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	*v = FValue[F, X]{}
+	n, err := readXML(dec, start)
+	if err != nil {
+		return err
+	}
+
+	value, ok := lookupXML(n, fieldPath((*new(F)).FieldName()))
+	if !ok {
+		return nil
+	}
+	if err := decodeXML(value, &v.X); err != nil {
+		return err
+	}
+	v.Found = true
+	return nil
+}
+
+// lookupJSON returns the value at path in the JSON object data.
+// It reports false if a key is missing or a value on the path is not an object.
+// An error is returned only if data is not an object.
+func lookupJSON(data []byte, path []string) ([]byte, bool, error) {
+	for i, key := range path {
+		s := jsonScanner{data: data, keys: [][]byte{[]byte(key)}}
+		fields, err := s.object(true)
+		if err == nil {
+			s.skipSpace()
+		}
+		if err != nil || s.pos != len(s.data) {
+			if i == 0 {
+				return nil, false, jsonObjectError(data)
+			}
+			return nil, false, nil
+		}
+
+		var ok bool
+		data, ok = findJSONField(fields, key)
+		if !ok {
+			return nil, false, nil
+		}
+	}
+	return data, true, nil
+}
+
+// lookupMsgpack returns the value at path in the msgpack map data.
+// It reports false if a key is missing or a value on the path is not a map.
+// An error is returned only if data is not a map.
+func lookupMsgpack(data []byte, path []string) ([]byte, bool, error) {
+	for i, key := range path {
+		s := msgpackScanner{data: data}
+		fields, err := s.mapFields([][]byte{[]byte(key)})
+		if err != nil || s.pos != len(s.data) {
+			if i == 0 {
+				return nil, false, msgpackMapError(data)
+			}
+			return nil, false, nil
+		}
+
+		var ok bool
+		data, ok = findMsgpackField(fields, key)
+		if !ok {
+			return nil, false, nil
+		}
+	}
+	return data, true, nil
+}
+
+// lookupPaths sets values[i] to the value at paths[i] in the map data. It leaves values[i] unset
+// if a key is missing or a value on the path is not a map. Each map on the way is scanned once for all paths.
+// scan scans the map data for the given keys and returns a function that finds the value of one of them.
+// It reports false if data is not a map.
+func lookupPaths[V any](data V, paths [][]string, values []V, scan func(data V, keys []string) (func(key string) (V, bool), bool)) bool {
+	keys := make([]string, len(paths))
+	for i, path := range paths {
+		keys[i] = path[0]
+	}
+	find, ok := scan(data, keys)
+	if !ok {
+		return false
+	}
+
+	done := make([]bool, len(paths))
+	for i, path := range paths {
+		if done[i] {
+			continue
+		}
+		value, ok := find(path[0])
+		if !ok {
+			continue
+		}
+		if len(path) == 1 {
+			values[i] = value
+			continue
+		}
+
+		// look up all paths below the same key with one scan of value
+		var nested []int
+		var rest [][]string
+		for j := i; j < len(paths); j++ {
+			if !done[j] && len(paths[j]) > 1 && paths[j][0] == path[0] {
+				nested = append(nested, j)
+				rest = append(rest, paths[j][1:])
+				done[j] = true
+			}
+		}
+		nestedValues := make([]V, len(rest))
+		lookupPaths(value, rest, nestedValues, scan)
+		for k, j := range nested {
+			values[j] = nestedValues[k]
+		}
+	}
+	return true
+}
+
+// byteKeys converts keys for the keys of a scanner.
+func byteKeys(keys []string) [][]byte {
+	b := make([][]byte, len(keys))
+	for i, key := range keys {
+		b[i] = []byte(key)
+	}
+	return b
+}
+
+// lookupJSONPaths returns the values at paths in the JSON object data, scanning each object once.
+// The value of a path is nil if a key is missing or a value on the path is not an object.
+// An error is returned only if data is not an object.
+func lookupJSONPaths(data []byte, paths [][]string) ([][]byte, error) {
+	values := make([][]byte, len(paths))
+	ok := lookupPaths(data, paths, values, func(data []byte, keys []string) (func(string) ([]byte, bool), bool) {
+		s := jsonScanner{data: data, keys: byteKeys(keys)}
+		fields, err := s.object(true)
+		if err == nil {
+			s.skipSpace()
+		}
+		if err != nil || s.pos != len(s.data) {
+			return nil, false
+		}
+		return func(key string) ([]byte, bool) { return findJSONField(fields, key) }, true
+	})
+	if !ok {
+		return nil, jsonObjectError(data)
+	}
+	return values, nil
+}
+
+// lookupMsgpackPaths returns the values at paths in the msgpack map data, scanning each map once.
+// The value of a path is nil if a key is missing or a value on the path is not a map.
+// An error is returned only if data is not a map.
+func lookupMsgpackPaths(data []byte, paths [][]string) ([][]byte, error) {
+	values := make([][]byte, len(paths))
+	ok := lookupPaths(data, paths, values, func(data []byte, keys []string) (func(string) ([]byte, bool), bool) {
+		s := msgpackScanner{data: data}
+		fields, err := s.mapFields(byteKeys(keys))
+		if err != nil || s.pos != len(s.data) {
+			return nil, false
+		}
+		return func(key string) ([]byte, bool) { return findMsgpackField(fields, key) }, true
+	})
+	if !ok {
+		return nil, msgpackMapError(data)
+	}
+	return values, nil
+}
+
+// nestJSON wraps the JSON value data into objects along path.
+func nestJSON(data []byte, path []string) ([]byte, error) {
+	for i := len(path) - 1; i >= 0; i-- {
+		key, err := json.Marshal(path[i])
+		if err != nil {
+			return nil, err
+		}
+		data = encodeJSONObject([]jsonField{{key: key, value: data}})
+	}
+	return data, nil
+}
+
+// nestMsgpack wraps the msgpack value data into maps along path.
+func nestMsgpack(data []byte, path []string) ([]byte, error) {
+	for i := len(path) - 1; i >= 0; i-- {
+		key, err := msgpack.Marshal(path[i])
+		if err != nil {
+			return nil, err
+		}
+		data, err = encodeMsgpackMap([]msgpackField{{key: key, value: data}})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// fieldPath splits the field name of a selector into the keys of its path.
+// A name starting with "/" is a JSON Pointer (RFC 6901), any other name is a single key.
+func fieldPath(name string) []string {
+	if !strings.HasPrefix(name, "/") {
+		return []string{name}
+	}
+
+	keys := strings.Split(name[1:], "/")
+	for i, key := range keys {
+		keys[i] = strings.ReplaceAll(strings.ReplaceAll(key, "~1", "/"), "~0", "~")
+	}
+	return keys
+}
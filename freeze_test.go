@@ -0,0 +1,100 @@
+package ijson_test
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Nikkolix/ijson"
+)
+
+func TestRegistry_Freeze(t *testing.T) {
+	t.Parallel()
+	r := frozenRegistry
+	require.NoError(t, ijson.RegisterTIn[Circle, Shape](r, "circle"))
+	assert.False(t, r.Frozen())
+
+	r.Freeze()
+	assert.True(t, r.Frozen())
+
+	newPoint := func() Shape { return &Point{} }
+	assert.ErrorIs(t, ijson.RegisterTIn[Square, Shape](r, "square"), ijson.ErrFrozen)
+	assert.ErrorIs(t, ijson.RegisterIn[Shape](r, "point", newPoint), ijson.ErrFrozen)
+	assert.ErrorIs(t, ijson.RegisterDefaultIn(r, func(string) Shape { return &Point{} }), ijson.ErrFrozen)
+	assert.ErrorIs(t, ijson.RegisterFIn[Shape, TestFSelector](r, "point", newPoint), ijson.ErrFrozen)
+	assert.ErrorIs(t, ijson.RegisterDefaultFIn[Shape, TestFSelector](r, func(string) Shape { return &Point{} }), ijson.ErrFrozen)
+	assert.ErrorIs(t, ijson.RegisterMIn[Shape, APIKind](r, [2]string{"v1", "Point"}, newPoint), ijson.ErrFrozen)
+	assert.ErrorIs(t, ijson.RegisterUntaggedIn(r, newPoint), ijson.ErrFrozen)
+	assert.ErrorIs(t, ijson.ReplaceIn[Shape](r, "circle", newPoint), ijson.ErrFrozen)
+	ok, err := ijson.UnregisterIn[Shape](r, "circle")
+	assert.ErrorIs(t, err, ijson.ErrFrozen)
+	assert.False(t, ok)
+	_, err = ijson.UnregisterFIn[Shape, TestFSelector](r, "point")
+	assert.ErrorIs(t, err, ijson.ErrFrozen)
+
+	var decider ijson.ScopedDecider[Shape, string, FrozenScope]
+	i, err := decider.Decide("circle")
+	require.NoError(t, err)
+	assert.Equal(t, &Circle{}, i)
+	x, ok := decider.Discriminate(&Circle{})
+	assert.True(t, ok)
+	assert.Equal(t, "circle", x)
+	assert.Len(t, ijson.RegistrationsIn[Shape, string](r), 1)
+
+	clone := r.Clone()
+	assert.False(t, clone.Frozen())
+	require.NoError(t, ijson.RegisterTIn[Square, Shape](clone, "square"))
+	_, ok = ijson.LookupIn[Shape](r, "square")
+	assert.False(t, ok)
+
+	snapshot := r.Snapshot()
+	r.Restore(snapshot)
+	assert.False(t, r.Frozen())
+	require.NoError(t, ijson.RegisterTIn[Square, Shape](r, "square"))
+
+	r.Freeze()
+	r.Reset()
+	assert.False(t, r.Frozen())
+	assert.Empty(t, ijson.RegistrationsIn[Shape, string](r))
+	require.NoError(t, ijson.RegisterTIn[Square, Shape](r, "square"))
+}
+
+var frozenRegistry = ijson.NewRegistry()
+
+type FrozenScope struct{}
+
+func (FrozenScope) Registry() *ijson.Registry { return frozenRegistry }
+
+func TestFreeze_DefaultRegistry(t *testing.T) {
+	registerShapes(t)
+	ijson.Freeze()
+	defer ijson.ResetRegistries()
+
+	assert.ErrorIs(t, ijson.RegisterT[Circle, Shape]("round"), ijson.ErrFrozen)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for range 100 {
+				var d ijson.RAdjacentDecodable[Shape, string, ijson.TypeData]
+				assert.NoError(t, json.Unmarshal([]byte(`{"type":"square","data":{"side":2}}`), &d))
+				assert.Equal(t, &Square{Side: 2}, d.I)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestRegisterTest_Frozen(t *testing.T) {
+	t.Parallel()
+	r := ijson.NewRegistry()
+	r.Freeze()
+
+	var tb fakeTB
+	ijson.RegisterTestIn[Shape](&tb, r, "circle", func() Shape { return &Circle{} })
+	assert.Equal(t, "ijson: register circle: ijson: registry is frozen", tb.fatal)
+	assert.Empty(t, tb.cleanups)
+}
@@ -11,16 +11,27 @@ type TestF struct{}
 
 func (TestF) FieldName() string { return "type" }
 
-func TestXFDecider_RegistryEntryWrongTypeError(t *testing.T) {
-	ResetRegistries()
+func TestTable_Selectors(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, RegisterFIn[TestInterface, TestF](r, TestTypeA, func() TestInterface { return &ValidTestStruct{} }))
 
-	key := typeKeyF[TestInterface, TestF, TestDiscriminator]{x: TestTypeA}
-	registries[key] = "not_a_factory"
-
-	var decider FDecider[TestInterface, TestF, TestDiscriminator]
-	mx := map[string]TestDiscriminator{"type": TestTypeA}
-	_, err := decider.Decide(mx)
+	assert.NotNil(t, loadTable[TestInterface, TestF, TestDiscriminator](r))
+	assert.Nil(t, loadTable[TestInterface, noSelector, TestDiscriminator](r))
+	assert.Nil(t, loadTable[TestInterface, TestF, string](r))
+}
 
-	require.Error(t, err)
-	assert.Equal(t, "registry[I: ijson.TestInterface, F: ijson.TestF, X: ijson.TestDiscriminator] entry should be func() I but is: string for X value typeA", err.Error())
+func TestFieldPath(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"type", []string{"type"}},
+		{"metadata.kind", []string{"metadata.kind"}},
+		{"/header/type", []string{"header", "type"}},
+		{"/a.b/c~1d/e~0f", []string{"a.b", "c/d", "e~f"}},
+		{"/", []string{""}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, fieldPath(tt.name), tt.name)
+	}
 }
@@ -1,10 +1,20 @@
 package ijson
 
 import (
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 type TestInterface interface {
@@ -25,15 +35,513 @@ const (
 	TestTypeA TestDiscriminator = "typeA"
 )
 
-func TestRegistryDecider_RegistryWrongTypeError(t *testing.T) {
-	ResetRegistries()
+func TestTable_CopyOnWrite(t *testing.T) {
+	r := NewRegistry()
+	require.NoError(t, RegisterTIn[ValidTestStruct, TestInterface](r, TestTypeA))
 
-	key := typeKey[TestInterface, TestDiscriminator]{x: TestTypeA}
-	registries[key] = "invalid_registry_type"
+	stored := loadTable[TestInterface, noSelector, TestDiscriminator](r)
+	require.NotNil(t, stored)
+	require.NoError(t, RegisterTIn[ValidTestStruct, TestInterface](r, TestDiscriminator("typeB")))
+	require.NoError(t, ReplaceIn[TestInterface](r, TestTypeA, func() TestInterface { return &ValidTestStruct{Value: "a"} }))
+	ok, err := UnregisterIn[TestInterface](r, TestDiscriminator("typeB"))
+	require.NoError(t, err)
+	assert.True(t, ok)
 
-	var decider RegistryDecider[TestInterface, TestDiscriminator]
-	_, err := decider.Decide(TestTypeA)
+	assert.Len(t, stored.factories, 1)
+	assert.Len(t, stored.list, 1)
+	assert.Equal(t, TestTypeA, stored.types[reflect.TypeFor[*ValidTestStruct]()])
 
-	require.Error(t, err)
-	assert.Equal(t, "registry[I: ijson.TestInterface, X: ijson.TestDiscriminator] entry should be func() I but is: string for X value typeA", err.Error())
+	current := loadTable[TestInterface, noSelector, TestDiscriminator](r)
+	assert.NotSame(t, stored, current)
+	i, ok := current.decide(TestTypeA)
+	assert.True(t, ok)
+	assert.Equal(t, "a", i.DoSomething())
+}
+
+func TestSwapIn_Concurrent(t *testing.T) {
+	r := NewRegistry()
+
+	var wg sync.WaitGroup
+	var unregistered atomic.Int32
+	for i := range 8 {
+		wg.Go(func() {
+			factory := func() TestInterface { return &ValidTestStruct{Value: strconv.Itoa(i)} }
+			_, ok, err := swapIn[TestInterface, noSelector](r, TestTypeA, factory)
+			assert.NoError(t, err)
+			if !ok {
+				unregistered.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), unregistered.Load(), "only the first swap sees no previous registration")
+	assert.Len(t, RegistrationsIn[TestInterface, TestDiscriminator](r), 1)
+}
+
+func TestTable_Nil(t *testing.T) {
+	var table *table[TestInterface, TestDiscriminator]
+	_, ok := table.factory(TestTypeA)
+	assert.False(t, ok)
+	_, ok = table.discriminator(reflect.TypeFor[*ValidTestStruct]())
+	assert.False(t, ok)
+	_, ok = table.decide(TestTypeA)
+	assert.False(t, ok)
+	assert.Nil(t, table.registrations())
+	assert.NotNil(t, table.clone().factories)
+}
+
+func TestSplitJSONObject(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		keys []string
+		ok   bool
+	}{
+		{name: "empty", data: ` { } `, keys: nil, ok: true},
+		{name: "members", data: `{"a":1,"b":"x","c":[1,{"d":true}],"e":null,"f":false,"g":-1.5e+3}`, keys: []string{`"a"`, `"b"`, `"c"`, `"e"`, `"f"`, `"g"`}, ok: true},
+		{name: "escapes", data: `{"a\"b":"\\\/\b\f\n\r\té"}`, keys: []string{`"a\"b"`}, ok: true},
+		{name: "empty array", data: `{"a":[ ]}`, keys: []string{`"a"`}, ok: true},
+		{name: "not an object", data: `[1]`},
+		{name: "trailing data", data: `{} {}`},
+		{name: "missing colon", data: `{"a" 1}`},
+		{name: "missing comma", data: `{"a":1 "b":2}`},
+		{name: "bad key", data: `{a:1}`},
+		{name: "bad value", data: `{"a":x}`},
+		{name: "bad literal", data: `{"a":tru}`},
+		{name: "bad array", data: `{"a":[1 2]}`},
+		{name: "bad array element", data: `{"a":[x]}`},
+		{name: "bad escape", data: `{"a":"\x"}`},
+		{name: "bad unicode escape", data: `{"a":"\u12g4"}`},
+		{name: "control character", data: "{\"a\":\"\x01\"}"},
+		{name: "unterminated string", data: `{"a":"b`},
+		{name: "leading zero", data: `{"a":01}`},
+		{name: "bad fraction", data: `{"a":1.}`},
+		{name: "bad exponent", data: `{"a":1e}`},
+		{name: "lone minus", data: `{"a":-}`},
+		{name: "unterminated object", data: `{"a":1`},
+		{name: "too deep", data: `{"a":` + strings.Repeat("[", maxJSONDepth+1) + strings.Repeat("]", maxJSONDepth+1) + `}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, ok := splitJSONObject([]byte(tt.data))
+			require.Equal(t, tt.ok, ok)
+
+			keys := make([]string, 0, len(fields))
+			for _, f := range fields {
+				keys = append(keys, string(f.key))
+			}
+			assert.Equal(t, len(tt.keys), len(keys))
+			for i := range tt.keys {
+				assert.Equal(t, tt.keys[i], keys[i])
+			}
+		})
+	}
+}
+
+type jsonKeyDiscriminator struct {
+	Kind    string `json:"kind,omitempty"`
+	Ignored string `json:"-"`
+	Version int
+}
+
+type selfDecodingDiscriminator struct{}
+
+func (*selfDecodingDiscriminator) UnmarshalJSON([]byte) error { return nil }
+
+func TestDiscriminatorJSON(t *testing.T) {
+	data := []byte(`{"KIND":"a","Ignored":"x","version":2,"other":{"kind":"b"},"kind":"c"}`)
+	assert.Equal(t, `{"KIND":"a","version":2,"kind":"c"}`, string(discriminatorJSON[jsonKeyDiscriminator](new(jsonBuffers), data)))
+
+	assert.Equal(t, string(data), string(discriminatorJSON[map[string]any](new(jsonBuffers), data)))
+	assert.Equal(t, string(data), string(discriminatorJSON[selfDecodingDiscriminator](new(jsonBuffers), data)))
+
+	escaped := []byte(`{"\u006bind":"a","other":1}`)
+	assert.Equal(t, `{"\u006bind":"a"}`, string(discriminatorJSON[jsonKeyDiscriminator](new(jsonBuffers), escaped)))
+
+	invalid := []byte(`{"kind":"a",}`)
+	assert.Equal(t, string(invalid), string(discriminatorJSON[jsonKeyDiscriminator](new(jsonBuffers), invalid)))
+
+	trailing := []byte(`{"kind":"a"} x`)
+	assert.Equal(t, string(trailing), string(discriminatorJSON[jsonKeyDiscriminator](new(jsonBuffers), trailing)))
+}
+
+func TestMergeJSON(t *testing.T) {
+	assert.Equal(t, `{"b":2,"a":1}`, string(mergeJSON([]byte(`{"b":2}`), []byte(`{"a":1}`))))
+	assert.Equal(t, `{"a":1,"b":3}`, string(mergeJSON([]byte(`{"b":3}`), []byte(`{"a":1,"b":2}`))))
+	assert.Equal(t, `{"a":1}`, string(mergeJSON([]byte(`"b"`), []byte(`{"a":1}`))))
+	assert.Equal(t, `null`, string(mergeJSON([]byte(`{"b":2}`), []byte(`null`))))
+	assert.Equal(t, `{"m":{"a":1,"k":"x"}}`, string(mergeJSON([]byte(`{"m":{"k":"x"}}`), []byte(`{"m":{"a":1,"k":"y"}}`))))
+	assert.Equal(t, `{"m":{"k":"x"}}`, string(mergeJSON([]byte(`{"m":{"k":"x"}}`), []byte(`{"m":[1]}`))))
+}
+
+func TestMergeMsgpack(t *testing.T) {
+	tag, err := msgpack.Marshal(map[string]string{"b": "2"})
+	require.NoError(t, err)
+	value, err := msgpack.Marshal(map[string]string{"a": "1"})
+	require.NoError(t, err)
+
+	merged, err := mergeMsgpack(tag, value)
+	require.NoError(t, err)
+	fields, ok := splitMsgpackMap(merged)
+	require.True(t, ok)
+	require.Len(t, fields, 2)
+
+	var key string
+	require.NoError(t, msgpack.Unmarshal(fields[0].key, &key))
+	assert.Equal(t, "b", key)
+
+	str, err := msgpack.Marshal("b")
+	require.NoError(t, err)
+	merged, err = mergeMsgpack(str, value)
+	require.NoError(t, err)
+	assert.Equal(t, value, merged)
+
+	merged, err = mergeMsgpack(tag, str)
+	require.NoError(t, err)
+	assert.Equal(t, str, merged)
+	nestedTag, err := msgpack.Marshal(map[string]any{"m": map[string]string{"k": "x"}})
+	require.NoError(t, err)
+	nestedValue, err := msgpack.Marshal(map[string]any{"m": map[string]string{"k": "y"}, "a": 1})
+	require.NoError(t, err)
+	merged, err = mergeMsgpack(nestedTag, nestedValue)
+	require.NoError(t, err)
+	var out map[string]any
+	require.NoError(t, msgpack.Unmarshal(merged, &out))
+	assert.Equal(t, map[string]any{"m": map[string]any{"k": "x"}, "a": int8(1)}, out)
+}
+
+func TestSplitMsgpackMap(t *testing.T) {
+	_, ok := splitMsgpackMap([]byte{0xc0})
+	assert.False(t, ok)
+
+	_, ok = splitMsgpackMap([]byte{0x81, 0xa1, 'a'})
+	assert.False(t, ok)
+
+	_, ok = splitMsgpackMap([]byte{0x81, 0xc1})
+	assert.False(t, ok)
+
+	_, ok = splitMsgpackMap([]byte{0x80, 0xc0})
+	assert.False(t, ok)
+}
+
+func TestMsgpackScanner_Value(t *testing.T) {
+	values := []any{
+		nil, true, false, 1, -1,
+		uint8(200), uint16(60000), uint32(4000000000), uint64(1 << 40),
+		int8(-100), int16(-30000), int32(-2000000000), int64(-1 << 40),
+		float32(1.5), 2.5,
+		"s", strings.Repeat("a", 40), strings.Repeat("a", 300), strings.Repeat("a", 70000),
+		[]byte("b"), make([]byte, 300), make([]byte, 70000),
+		[]int{1, 2}, make([]int, 20), make([]int, 70000),
+		map[string]int{"a": 1}, map[int]int{1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 6: 6, 7: 7, 8: 8, 9: 9, 10: 10, 11: 11, 12: 12, 13: 13, 14: 14, 15: 15, 16: 16},
+	}
+	for i := range 70000 {
+		if i == 0 {
+			values = append(values, make(map[int]int, 70000))
+		}
+		values[len(values)-1].(map[int]int)[i] = i
+	}
+
+	for _, v := range values {
+		data, err := msgpack.Marshal(v)
+		require.NoError(t, err)
+
+		s := msgpackScanner{data: data}
+		raw, err := s.value()
+		require.NoError(t, err, "%T", v)
+		assert.Equal(t, len(data), len(raw), "%T", v)
+
+		s = msgpackScanner{data: data[:len(data)-1]}
+		_, err = s.value()
+		assert.Error(t, err, "%T", v)
+	}
+
+	exts := [][]byte{
+		{0xd4, 1, 0}, {0xd5, 1, 0, 0}, {0xd6, 1, 0, 0, 0, 0}, {0xd7, 1, 0, 0, 0, 0, 0, 0, 0, 0},
+		append([]byte{0xd8, 1}, make([]byte, 16)...),
+		{0xc7, 1, 1, 0}, {0xc8, 0, 1, 1, 0}, {0xc9, 0, 0, 0, 1, 1, 0},
+	}
+	for _, data := range exts {
+		s := msgpackScanner{data: data}
+		raw, err := s.value()
+		require.NoError(t, err)
+		assert.Equal(t, data, raw)
+
+		s = msgpackScanner{data: data[:len(data)-1]}
+		_, err = s.value()
+		assert.Error(t, err)
+	}
+
+	for _, data := range [][]byte{{0xc1}, {}, {0xc7}, {0xc8}, {0xc9}, {0xd9}, {0xda}, {0xdb}, {0xdc}, {0xdd}, {0xde}, {0xdf}} {
+		s := msgpackScanner{data: data}
+		_, err := s.value()
+		assert.Error(t, err)
+	}
+
+	deep := append(bytes.Repeat([]byte{0x91}, maxMsgpackDepth+1), 0xc0)
+	s := msgpackScanner{data: deep}
+	_, err := s.value()
+	assert.Error(t, err)
+}
+
+func TestMsgpackScanner_Keys(t *testing.T) {
+	s := msgpackScanner{data: []byte{0xde, 0}}
+	_, err := s.mapLen()
+	assert.Error(t, err)
+
+	s = msgpackScanner{data: []byte{0xdf, 0, 0, 0, 0}}
+	n, err := s.mapLen()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	s = msgpackScanner{data: []byte{}}
+	_, err = s.mapLen()
+	assert.Error(t, err)
+
+	keys := [][]byte{[]byte(strings.Repeat("k", 300))}
+	for _, data := range [][]byte{{0xd9, 0x01, 'a'}, {0xda, 0x00, 0x01, 'a'}, {0xdb, 0, 0, 0, 1, 'a'}} {
+		assert.False(t, matchMsgpackKey(data, keys))
+	}
+	for _, data := range [][]byte{{}, {0x01}, {0xd9}, {0xa2, 'a'}} {
+		assert.False(t, matchMsgpackKey(data, keys))
+	}
+
+	key, err := msgpack.Marshal(strings.Repeat("k", 300))
+	require.NoError(t, err)
+	assert.True(t, matchMsgpackKey(key, keys))
+}
+
+func TestMergeCBOR(t *testing.T) {
+	tag, err := cbor.Marshal(map[string]any{"m": map[string]string{"k": "x"}, "b": "2"})
+	require.NoError(t, err)
+	value, err := cbor.Marshal(map[string]any{"m": map[string]string{"k": "y"}, "a": 1})
+	require.NoError(t, err)
+
+	var out map[string]any
+	require.NoError(t, cbor.Unmarshal(mergeCBOR(tag, value), &out))
+	assert.Equal(t, map[string]any{"m": map[any]any{"k": "x"}, "a": uint64(1), "b": "2"}, out)
+
+	str, err := cbor.Marshal("b")
+	require.NoError(t, err)
+	assert.Equal(t, value, mergeCBOR(str, value))
+	assert.Equal(t, str, mergeCBOR(tag, str))
+}
+
+func TestMergeBSON(t *testing.T) {
+	tag, err := bson.Marshal(bson.D{{Key: "m", Value: bson.D{{Key: "k", Value: "x"}}}, {Key: "b", Value: "2"}})
+	require.NoError(t, err)
+	value, err := bson.Marshal(bson.D{{Key: "m", Value: bson.D{{Key: "k", Value: "y"}, {Key: "l", Value: "z"}}}, {Key: "a", Value: 1}})
+	require.NoError(t, err)
+
+	merged, err := mergeBSON(tag, value)
+	require.NoError(t, err)
+	assert.Equal(t, `{"b": "2","m": {"k": "x","l": "z"},"a": {"$numberInt":"1"}}`, bson.Raw(merged).String())
+
+	merged, err = mergeBSON([]byte{1}, value)
+	require.NoError(t, err)
+	assert.Equal(t, value, merged)
+	merged, err = mergeBSON(tag, []byte{1})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1}, merged)
+
+	_, ok, err := lookupBSON(value, []string{"a", "k"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	v, ok, err := lookupBSON(value, []string{"m", "l"})
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "z", v.StringValue())
+}
+
+func TestLookupPaths(t *testing.T) {
+	data := map[string]any{
+		"a": map[string]any{"x": 1, "y": 2, "z": map[string]any{"q": 3}},
+		"b": 4,
+		"c": 5,
+	}
+	scans := 0
+	scan := func(data any, keys []string) (func(string) (any, bool), bool) {
+		scans++
+		m, ok := data.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		return func(key string) (any, bool) {
+			v, ok := m[key]
+			return v, ok
+		}, true
+	}
+
+	paths := [][]string{{"a", "x"}, {"b"}, {"a", "z", "q"}, {"a", "y"}, {"c", "d"}, {"e"}, {"a", "w"}}
+	values := make([]any, len(paths))
+	require.True(t, lookupPaths[any](data, paths, values, scan))
+	assert.Equal(t, []any{1, 4, 3, 2, nil, nil, nil}, values)
+	assert.Equal(t, 4, scans, "the root, a, a/z and c are scanned once each")
+
+	assert.False(t, lookupPaths[any](1, paths, values, scan))
+
+	object := []byte(`{"kind":"dog","meta":{"v":"1","x":[1]},"other":{"v":"2"}}`)
+	jsonValues, err := lookupJSONPaths(object, [][]string{{"meta", "v"}, {"kind"}, {"other", "v"}, {"missing"}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte(`"1"`), []byte(`"dog"`), []byte(`"2"`), nil}, jsonValues)
+	_, err = lookupJSONPaths([]byte(`[]`), [][]string{{"kind"}})
+	assert.Error(t, err)
+
+	doc, err := bson.Marshal(bson.D{{Key: "m", Value: bson.D{{Key: "l", Value: "z"}}}, {Key: "a", Value: 1}})
+	require.NoError(t, err)
+	bsonValues, err := lookupBSONPaths(doc, [][]string{{"a", "k"}, {"m", "l"}, {"a"}})
+	require.NoError(t, err)
+	assert.Zero(t, bsonValues[0].Type)
+	assert.Equal(t, "z", bsonValues[1].StringValue())
+	assert.Equal(t, int32(1), bsonValues[2].Int32())
+}
+
+func TestMergeXML(t *testing.T) {
+	parse := func(data string) *xmlNode {
+		d := xml.NewDecoder(strings.NewReader(data))
+		tok, err := d.Token()
+		require.NoError(t, err)
+		n, err := readXML(d, tok.(xml.StartElement))
+		require.NoError(t, err)
+		return n
+	}
+	write := func(n *xmlNode) string {
+		var buf bytes.Buffer
+		e := xml.NewEncoder(&buf)
+		require.NoError(t, writeXML(e, n))
+		require.NoError(t, e.Flush())
+		return buf.String()
+	}
+
+	tag := parse(`<t a="1" b="2">text<m><k>x</k></m><c>3</c></t>`)
+	value := parse(`<v b="0"><!--c--><m><k>y</k><l>z</l></m><d>4</d></v>`)
+	assert.Equal(t, `<v a="1" b="2"><c>3</c><!--c--><m><k>x</k><l>z</l></m><d>4</d></v>`, write(mergeXML(tag, value)))
+
+	n, ok := lookupXML(value, []string{"m", "@k"})
+	assert.False(t, ok)
+	assert.Nil(t, n)
+	_, ok = lookupXML(value, []string{"@a", "m"})
+	assert.False(t, ok)
+	n, ok = lookupXML(value, []string{"m", "l"})
+	require.True(t, ok)
+	assert.Equal(t, "<l>z</l>", write(n))
+}
+
+func TestReadXML_Depth(t *testing.T) {
+	read := func(depth int) error {
+		data := strings.Repeat("<a>", depth) + strings.Repeat("</a>", depth)
+		d := xml.NewDecoder(strings.NewReader(data))
+		tok, err := d.Token()
+		require.NoError(t, err)
+		_, err = readXML(d, tok.(xml.StartElement))
+		return err
+	}
+
+	assert.NoError(t, read(maxXMLDepth))
+	assert.ErrorIs(t, read(maxXMLDepth+1), errXMLDepth)
+}
+
+func TestCBORScanner_Value(t *testing.T) {
+	values := []any{
+		nil, true, false, 1, -1, uint64(1 << 40), -1 << 40, float32(1.5), 2.5,
+		"s", strings.Repeat("a", 300), strings.Repeat("a", 70000), []byte("b"),
+		[]int{1, 2}, make([]int, 70000), map[string]int{"a": 1}, map[int]int{1: 1, 2: 2},
+		cbor.Tag{Number: 1000, Content: "x"},
+	}
+	for _, v := range values {
+		data, err := cbor.Marshal(v)
+		require.NoError(t, err)
+
+		s := cborScanner{data: data}
+		raw, err := s.value()
+		require.NoError(t, err, "%T", v)
+		assert.Equal(t, len(data), len(raw), "%T", v)
+
+		s = cborScanner{data: data[:len(data)-1]}
+		_, err = s.value()
+		assert.Error(t, err, "%T", v)
+	}
+
+	// indefinite length byte string, text string, array and map
+	for _, data := range [][]byte{
+		{0x5f, 0x41, 'a', 0xff}, {0x7f, 0x61, 'a', 0x61, 'b', 0xff},
+		{0x9f, 0x01, 0x9f, 0xff, 0xff}, {0xbf, 0x61, 'a', 0x01, 0xff},
+	} {
+		s := cborScanner{data: data}
+		raw, err := s.value()
+		require.NoError(t, err)
+		assert.Equal(t, data, raw)
+
+		s = cborScanner{data: data[:len(data)-1]}
+		_, err = s.value()
+		assert.Error(t, err)
+	}
+
+	for _, data := range [][]byte{{}, {0xff}, {0x1c}, {0x5f, 0x61, 'a', 0xff}, {0x5f, 0x5f, 0xff, 0xff}, {0x18}} {
+		s := cborScanner{data: data}
+		_, err := s.value()
+		assert.Error(t, err, "%x", data)
+	}
+
+	// counts exceeding the remaining bytes, where 2^63 map entries would overflow to 0 items
+	for _, data := range [][]byte{
+		{0xbb, 0x80, 0, 0, 0, 0, 0, 0, 0, 0x01},
+		{0x9b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+	} {
+		s := cborScanner{data: data}
+		_, err := s.value()
+		assert.Error(t, err, "%x", data)
+	}
+
+	deep := append(bytes.Repeat([]byte{0x81}, maxCBORDepth+1), 0xf6)
+	s := cborScanner{data: deep}
+	_, err := s.value()
+	assert.Error(t, err)
+
+	fields, ok := splitCBORMap([]byte{0xbf, 0x61, 'a', 0x01, 0x01, 0x02, 0xff})
+	require.True(t, ok)
+	require.Len(t, fields, 2)
+	assert.True(t, matchCBORKey(fields[0].key, [][]byte{[]byte("a")}))
+	assert.False(t, matchCBORKey(fields[1].key, [][]byte{[]byte("a")}))
+	assert.False(t, matchCBORKey([]byte{0x7f, 0x61, 'a', 0xff}, [][]byte{[]byte("a")}))
+
+	_, ok = splitCBORMap([]byte{0xa1, 0x61, 'a'})
+	assert.False(t, ok)
+	_, ok = splitCBORMap([]byte{0x80})
+	assert.False(t, ok)
+}
+
+type msgpackKeyDiscriminator struct {
+	Kind    string `msgpack:"kind,omitempty"`
+	Ignored string `msgpack:"-"`
+	Version int
+}
+
+type selfDecodingMsgpackDiscriminator struct{}
+
+func (*selfDecodingMsgpackDiscriminator) DecodeMsgpack(*msgpack.Decoder) error { return nil }
+
+func TestDiscriminatorMsgpack(t *testing.T) {
+	data, err := msgpack.Marshal(map[string]any{"kind": "a", "Ignored": "x", "Version": 2, "other": map[string]any{"kind": "b"}})
+	require.NoError(t, err)
+
+	tag, err := discriminatorMsgpack[msgpackKeyDiscriminator](data)
+	require.NoError(t, err)
+	var out map[string]any
+	require.NoError(t, msgpack.Unmarshal(tag, &out))
+	assert.Equal(t, map[string]any{"kind": "a", "Version": int8(2)}, out)
+
+	tag, err = discriminatorMsgpack[map[string]any](data)
+	require.NoError(t, err)
+	assert.Equal(t, data, tag)
+
+	tag, err = discriminatorMsgpack[selfDecodingMsgpackDiscriminator](data)
+	require.NoError(t, err)
+	assert.Equal(t, data, tag)
+
+	tag, err = discriminatorMsgpack[msgpackKeyDiscriminator]([]byte{0xc0})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xc0}, tag)
 }
@@ -0,0 +1,89 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"reflect"
+	"slices"
+)
+
+// Registration describes a factory registered for a discriminator value.
+type Registration[I any, X comparable] struct {
+	X       X            // The discriminator value
+	Type    reflect.Type // The concrete type returned by the factory
+	Factory func() I     // The factory
+}
+
+// Registrations returns the factories registered for interface I and discriminator X
+// in the default registry, in registration order.
+func Registrations[I any, X comparable]() []Registration[I, X] {
+	return RegistrationsIn[I, X](defaultRegistry)
+}
+
+// RegistrationsIn returns the factories registered for interface I and discriminator X
+// in registry r, in registration order.
+func RegistrationsIn[I any, X comparable](r *Registry) []Registration[I, X] {
+	return slices.Clone(loadTable[I, noSelector, X](r).registrations())
+}
+
+// Lookup returns the factory registered for interface I and discriminator value x in the default registry.
+// Fallbacks registered with RegisterDefault are not considered.
+func Lookup[I any, X comparable](x X) (func() I, bool) {
+	return LookupIn[I](defaultRegistry, x)
+}
+
+// LookupIn returns the factory registered for interface I and discriminator value x in registry r.
+// Fallbacks registered with RegisterDefaultIn are not considered.
+func LookupIn[I any, X comparable](r *Registry, x X) (func() I, bool) {
+	return loadTable[I, noSelector, X](r).factory(x)
+}
+
+// DiscriminatorFor returns the discriminator used to marshal the concrete type t as interface I
+// in the default registry, which is the first value registered for t.
+func DiscriminatorFor[I any, X comparable](t reflect.Type) (X, bool) {
+	return DiscriminatorForIn[I, X](defaultRegistry, t)
+}
+
+// DiscriminatorForIn returns the discriminator used to marshal the concrete type t as interface I
+// in registry r, which is the first value registered for t.
+func DiscriminatorForIn[I any, X comparable](r *Registry, t reflect.Type) (X, bool) {
+	return loadTable[I, noSelector, X](r).discriminator(t)
+}
+
+// RegistrationsF returns the factories registered for interface I, field selector F and discriminator X
+// in the default registry, in registration order.
+func RegistrationsF[I any, F FSelector, X comparable]() []Registration[I, X] {
+	return RegistrationsFIn[I, F, X](defaultRegistry)
+}
+
+// RegistrationsFIn returns the factories registered for interface I, field selector F and discriminator X
+// in registry r, in registration order.
+func RegistrationsFIn[I any, F FSelector, X comparable](r *Registry) []Registration[I, X] {
+	return slices.Clone(loadTable[I, F, X](r).registrations())
+}
+
+// LookupF returns the factory registered for interface I, field selector F and discriminator value x
+// in the default registry. Fallbacks registered with RegisterDefaultF are not considered.
+func LookupF[I any, F FSelector, X comparable](x X) (func() I, bool) {
+	return LookupFIn[I, F](defaultRegistry, x)
+}
+
+// LookupFIn returns the factory registered for interface I, field selector F and discriminator value x
+// in registry r. Fallbacks registered with RegisterDefaultFIn are not considered.
+func LookupFIn[I any, F FSelector, X comparable](r *Registry, x X) (func() I, bool) {
+	return loadTable[I, F, X](r).factory(x)
+}
+
+// DiscriminatorForF returns the discriminator used to marshal the concrete type t as interface I
+// with field selector F in the default registry, which is the first value registered for t.
+func DiscriminatorForF[I any, F FSelector, X comparable](t reflect.Type) (X, bool) {
+	return DiscriminatorForFIn[I, F, X](defaultRegistry, t)
+}
+
+// DiscriminatorForFIn returns the discriminator used to marshal the concrete type t as interface I
+// with field selector F in registry r, which is the first value registered for t.
+func DiscriminatorForFIn[I any, F FSelector, X comparable](r *Registry, t reflect.Type) (X, bool) {
+	return loadTable[I, F, X](r).discriminator(t)
+}
@@ -0,0 +1,84 @@
+package ijson_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Nikkolix/ijson"
+)
+
+func TestRegistrations(t *testing.T) {
+	registerShapes(t)
+	require.NoError(t, ijson.RegisterT[Circle, Shape]("round"))
+
+	registrations := ijson.Registrations[Shape, string]()
+	require.Len(t, registrations, 3)
+	assert.Equal(t, "circle", registrations[0].X)
+	assert.Equal(t, reflect.TypeFor[*Circle](), registrations[0].Type)
+	assert.Equal(t, "square", registrations[1].X)
+	assert.Equal(t, reflect.TypeFor[*Square](), registrations[1].Type)
+	assert.Equal(t, "round", registrations[2].X)
+	assert.Equal(t, &Circle{}, registrations[2].Factory())
+
+	registrations[0].X = "changed"
+	assert.Equal(t, "circle", ijson.Registrations[Shape, string]()[0].X)
+
+	assert.Empty(t, ijson.Registrations[Shape, int]())
+	assert.Empty(t, ijson.RegistrationsIn[Shape, string](ijson.NewRegistry()))
+}
+
+func TestLookup(t *testing.T) {
+	registerShapes(t)
+
+	factory, ok := ijson.Lookup[Shape]("square")
+	require.True(t, ok)
+	assert.Equal(t, &Square{}, factory())
+
+	_, ok = ijson.Lookup[Shape]("hexagon")
+	assert.False(t, ok)
+
+	require.NoError(t, ijson.RegisterDefault(func(x string) Shape { return &UnknownShape{} }))
+	_, ok = ijson.Lookup[Shape]("hexagon")
+	assert.False(t, ok)
+}
+
+func TestDiscriminatorFor(t *testing.T) {
+	registerShapes(t)
+	require.NoError(t, ijson.RegisterT[Circle, Shape]("round"))
+
+	x, ok := ijson.DiscriminatorFor[Shape, string](reflect.TypeFor[*Circle]())
+	assert.True(t, ok)
+	assert.Equal(t, "circle", x)
+
+	_, ok = ijson.DiscriminatorFor[Shape, string](reflect.TypeFor[Circle]())
+	assert.False(t, ok)
+	_, ok = ijson.DiscriminatorFor[Shape, int](reflect.TypeFor[*Circle]())
+	assert.False(t, ok)
+}
+
+func TestRegistrationsF(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterF[XFTestInterface, TestFSelector]("B", func() XFTestInterface { return &XB{} }))
+	require.NoError(t, ijson.RegisterF[XFTestInterface, TestFSelector]("A", func() XFTestInterface { return &XA{} }))
+
+	registrations := ijson.RegistrationsF[XFTestInterface, TestFSelector, string]()
+	require.Len(t, registrations, 2)
+	assert.Equal(t, "B", registrations[0].X)
+	assert.Equal(t, reflect.TypeFor[*XA](), registrations[1].Type)
+	assert.Empty(t, ijson.Registrations[XFTestInterface, string]())
+
+	factory, ok := ijson.LookupF[XFTestInterface, TestFSelector]("A")
+	require.True(t, ok)
+	assert.Equal(t, &XA{}, factory())
+	_, ok = ijson.LookupF[XFTestInterface, TestFSelector]("C")
+	assert.False(t, ok)
+
+	x, ok := ijson.DiscriminatorForF[XFTestInterface, TestFSelector, string](reflect.TypeFor[*XB]())
+	assert.True(t, ok)
+	assert.Equal(t, "B", x)
+	_, ok = ijson.DiscriminatorForF[XFTestInterface, TestFSelector, string](reflect.TypeFor[*XUntyped]())
+	assert.False(t, ok)
+}
@@ -0,0 +1,479 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// jsonField is a member of a JSON object with its key and value kept as raw bytes.
+// The key includes its surrounding quotes.
+type jsonField struct {
+	key   []byte
+	value []byte
+}
+
+var errJSONSyntax = errors.New("ijson: invalid json")
+
+// splitJSONObject splits a JSON object into its members without decoding them.
+// It reports false if data is not a single, well-formed JSON object.
+func splitJSONObject(data []byte) ([]jsonField, bool) {
+	s := jsonScanner{data: data}
+	fields, err := s.object(true)
+	if err != nil {
+		return nil, false
+	}
+	s.skipSpace()
+	if s.pos != len(s.data) {
+		return nil, false
+	}
+	return fields, true
+}
+
+// jsonBuffers holds the buffers of discriminatorJSON, reused through jsonBufferPool.
+type jsonBuffers struct {
+	fields []jsonField
+	object []byte
+}
+
+var jsonBufferPool = sync.Pool{New: func() any { return new(jsonBuffers) }}
+
+// maxPooledJSONBuffer limits the size of buffers kept in jsonBufferPool.
+const maxPooledJSONBuffer = 64 << 10
+
+// release returns b to jsonBufferPool. Results of discriminatorJSON must not be used afterward.
+func (b *jsonBuffers) release() {
+	if cap(b.object) > maxPooledJSONBuffer {
+		return
+	}
+	clear(b.fields) // do not keep the payload alive
+	b.fields = b.fields[:0]
+	b.object = b.object[:0]
+	jsonBufferPool.Put(b)
+}
+
+// discriminatorJSON returns the members of the JSON object data that can be decoded into X,
+// built in the buffers of b. The values are not decoded, so decoding X only tokenizes these members.
+// If the members of X cannot be determined or data is not an object, data is returned unchanged.
+func discriminatorJSON[X any](b *jsonBuffers, data []byte) []byte {
+	keys, ok := jsonKeys(reflect.TypeFor[X]())
+	if !ok {
+		return data
+	}
+
+	s := jsonScanner{data: data, keys: keys, fields: b.fields[:0]}
+	fields, err := s.object(true)
+	if err != nil {
+		return data
+	}
+	s.skipSpace()
+	if s.pos != len(s.data) {
+		return data
+	}
+	b.fields = fields
+	b.object = appendJSONObject(b.object[:0], fields)
+	return b.object
+}
+
+var jsonKeyCache sync.Map // map[reflect.Type][][]byte
+
+// jsonKeys returns the object keys encoding/json may decode into a value of type t.
+// The result may contain more keys than t actually uses, but never less.
+// It reports false if t is not a struct or decodes itself.
+func jsonKeys(t reflect.Type) ([][]byte, bool) {
+	if cached, ok := jsonKeyCache.Load(t); ok {
+		keys := cached.([][]byte)
+		return keys, keys != nil
+	}
+
+	keys := collectJSONKeys(t)
+	jsonKeyCache.Store(t, keys)
+	return keys, keys != nil
+}
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+func collectJSONKeys(t reflect.Type) [][]byte {
+	if t.Kind() != reflect.Struct || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+
+	keys := [][]byte{}
+	for _, f := range reflect.VisibleFields(t) {
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue // a tag of "-," names the key "-"
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+		keys = append(keys, []byte(name))
+	}
+	return keys
+}
+
+// matchJSONKey reports whether the raw JSON string key case-insensitively equals one of keys.
+// Keys containing escape sequences are always matched.
+func matchJSONKey(key []byte, keys [][]byte) bool {
+	name := key[1 : len(key)-1]
+	if bytes.IndexByte(name, '\\') >= 0 {
+		return true
+	}
+
+	for _, k := range keys {
+		if bytes.EqualFold(name, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// findJSONField returns the value of the last member of fields with the given key.
+func findJSONField(fields []jsonField, key string) ([]byte, bool) {
+	var value []byte
+	found := false
+	for _, f := range fields {
+		if name, ok := unquoteJSONKey(f.key); ok && name == key {
+			value, found = f.value, true
+		}
+	}
+	return value, found
+}
+
+// unquoteJSONKey returns the unquoted value of a raw JSON string.
+func unquoteJSONKey(key []byte) (string, bool) {
+	if bytes.IndexByte(key, '\\') < 0 {
+		return string(key[1 : len(key)-1]), true
+	}
+
+	var name string
+	if err := json.Unmarshal(key, &name); err != nil {
+		return "", false
+	}
+	return name, true
+}
+
+// isJSONNull reports whether data is the JSON literal null.
+func isJSONNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+// jsonObjectError returns the error encoding/json reports for data that is not a JSON object.
+func jsonObjectError(data []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	return errJSONSyntax
+}
+
+// mergeJSON merges the members of the JSON object tag into the JSON object value.
+// Members of value that also exist in tag are replaced in place, or merged if both are objects,
+// all other members of tag are prepended.
+// If either input is not an object, value is returned unchanged.
+func mergeJSON(tag, value []byte) []byte {
+	tagFields, ok := splitJSONObject(tag)
+	if !ok {
+		return value
+	}
+	valueFields, ok := splitJSONObject(value)
+	if !ok {
+		return value
+	}
+
+	merged := make([]jsonField, 0, len(tagFields)+len(valueFields))
+	used := make([]bool, len(tagFields))
+	for _, vf := range valueFields {
+		for i, tf := range tagFields {
+			if bytes.Equal(tf.key, vf.key) {
+				if tf.value[0] == '{' && vf.value[0] == '{' {
+					vf.value = mergeJSON(tf.value, vf.value)
+				} else {
+					vf.value = tf.value
+				}
+				used[i] = true
+				break
+			}
+		}
+		merged = append(merged, vf)
+	}
+
+	prefix := make([]jsonField, 0, len(tagFields))
+	for i, tf := range tagFields {
+		if !used[i] {
+			prefix = append(prefix, tf)
+		}
+	}
+	return encodeJSONObject(append(prefix, merged...))
+}
+
+// encodeJSONObject writes the fields as a compact JSON object.
+func encodeJSONObject(fields []jsonField) []byte {
+	size := 2
+	for _, f := range fields {
+		size += len(f.key) + len(f.value) + 2
+	}
+	return appendJSONObject(make([]byte, 0, size), fields)
+}
+
+// appendJSONObject appends the fields as a compact JSON object to buf.
+func appendJSONObject(buf []byte, fields []jsonField) []byte {
+	buf = append(buf, '{')
+	for i, f := range fields {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, f.key...)
+		buf = append(buf, ':')
+		buf = append(buf, f.value...)
+	}
+	return append(buf, '}')
+}
+
+// maxJSONDepth mirrors the nesting limit of encoding/json.
+const maxJSONDepth = 10000
+
+// jsonScanner is a minimal validating JSON scanner that only records value boundaries.
+// If keys is set, only top-level members with a matching key are collected.
+// Collected members are appended to fields.
+type jsonScanner struct {
+	data   []byte
+	keys   [][]byte
+	fields []jsonField
+	pos    int
+	depth  int
+}
+
+func (s *jsonScanner) skipSpace() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\n', '\r':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (s *jsonScanner) peek() byte {
+	if s.pos < len(s.data) {
+		return s.data[s.pos]
+	}
+	return 0
+}
+
+// object scans a JSON object and returns its members if collect is set.
+func (s *jsonScanner) object(collect bool) ([]jsonField, error) {
+	s.skipSpace()
+	if s.peek() != '{' {
+		return nil, errJSONSyntax
+	}
+	s.pos++
+
+	var fields []jsonField
+	if collect {
+		fields = s.fields
+	}
+	s.skipSpace()
+	if s.peek() == '}' {
+		s.pos++
+		return fields, nil
+	}
+
+	for {
+		s.skipSpace()
+		start := s.pos
+		if err := s.string(); err != nil {
+			return nil, err
+		}
+		key := s.data[start:s.pos]
+
+		s.skipSpace()
+		if s.peek() != ':' {
+			return nil, errJSONSyntax
+		}
+		s.pos++
+
+		value, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		if collect && (s.keys == nil || matchJSONKey(key, s.keys)) {
+			fields = append(fields, jsonField{key: key, value: value})
+		}
+
+		s.skipSpace()
+		switch s.peek() {
+		case ',':
+			s.pos++
+		case '}':
+			s.pos++
+			return fields, nil
+		default:
+			return nil, errJSONSyntax
+		}
+	}
+}
+
+// value scans a single JSON value and returns its raw bytes.
+func (s *jsonScanner) value() ([]byte, error) {
+	s.skipSpace()
+	start := s.pos
+
+	s.depth++
+	if s.depth > maxJSONDepth {
+		return nil, errJSONSyntax
+	}
+
+	var err error
+	switch c := s.peek(); {
+	case c == '{':
+		_, err = s.object(false)
+	case c == '[':
+		err = s.array()
+	case c == '"':
+		err = s.string()
+	case c == 't':
+		err = s.literal("true")
+	case c == 'f':
+		err = s.literal("false")
+	case c == 'n':
+		err = s.literal("null")
+	case c == '-' || (c >= '0' && c <= '9'):
+		err = s.number()
+	default:
+		err = errJSONSyntax
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.depth--
+	return s.data[start:s.pos], nil
+}
+
+func (s *jsonScanner) array() error {
+	s.pos++
+	s.skipSpace()
+	if s.peek() == ']' {
+		s.pos++
+		return nil
+	}
+
+	for {
+		if _, err := s.value(); err != nil {
+			return err
+		}
+		s.skipSpace()
+		switch s.peek() {
+		case ',':
+			s.pos++
+		case ']':
+			s.pos++
+			return nil
+		default:
+			return errJSONSyntax
+		}
+	}
+}
+
+func (s *jsonScanner) string() error {
+	if s.peek() != '"' {
+		return errJSONSyntax
+	}
+	s.pos++
+
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case c == '"':
+			s.pos++
+			return nil
+		case c == '\\':
+			s.pos++
+			switch s.peek() {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				s.pos++
+			case 'u':
+				s.pos++
+				for range 4 {
+					if !isHex(s.peek()) {
+						return errJSONSyntax
+					}
+					s.pos++
+				}
+			default:
+				return errJSONSyntax
+			}
+		case c < 0x20:
+			return errJSONSyntax
+		default:
+			s.pos++
+		}
+	}
+	return errJSONSyntax
+}
+
+func (s *jsonScanner) literal(lit string) error {
+	if !bytes.HasPrefix(s.data[s.pos:], []byte(lit)) {
+		return errJSONSyntax
+	}
+	s.pos += len(lit)
+	return nil
+}
+
+func (s *jsonScanner) number() error {
+	if s.peek() == '-' {
+		s.pos++
+	}
+
+	switch c := s.peek(); {
+	case c == '0':
+		s.pos++
+	case c >= '1' && c <= '9':
+		s.digits()
+	default:
+		return errJSONSyntax
+	}
+
+	if s.peek() == '.' {
+		s.pos++
+		if !isDigit(s.peek()) {
+			return errJSONSyntax
+		}
+		s.digits()
+	}
+
+	if c := s.peek(); c == 'e' || c == 'E' {
+		s.pos++
+		if c := s.peek(); c == '+' || c == '-' {
+			s.pos++
+		}
+		if !isDigit(s.peek()) {
+			return errJSONSyntax
+		}
+		s.digits()
+	}
+	return nil
+}
+
+func (s *jsonScanner) digits() {
+	for isDigit(s.peek()) {
+		s.pos++
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var (
+	_ json.Marshaler   = DecodableMap[string, any, any, RegistryDecider[any, any], Strict]{}
+	_ json.Unmarshaler = &DecodableMap[string, any, any, RegistryDecider[any, any], Strict]{}
+
+	_ msgpack.Marshaler     = DecodableMap[string, any, any, RegistryDecider[any, any], Strict]{}
+	_ msgpack.Unmarshaler   = &DecodableMap[string, any, any, RegistryDecider[any, any], Strict]{}
+	_ msgpack.CustomEncoder = DecodableMap[string, any, any, RegistryDecider[any, any], Strict]{}
+	_ msgpack.CustomDecoder = &DecodableMap[string, any, any, RegistryDecider[any, any], Strict]{}
+)
+
+// DecodableMap is a generic map for polymorphic (de)serialization of objects whose values share an interface.
+// Every value is decoded like a Decodable[I, X, D], and null values are nil.
+// K is the key type, which must be usable as a map key by the codec, e.g. a string or integer kind.
+// I is the interface type, X is the discriminator type, D is the decider
+// and P is the policy for values that fail to decode.
+type DecodableMap[K comparable, I any, X any, D Decider[I, X], P ElementPolicy] map[K]I
+
+// RDecodableMap is a type alias for DecodableMap using RegistryDecider.
+type RDecodableMap[K comparable, I any, X comparable, P ElementPolicy] = DecodableMap[K, I, X, RegistryDecider[I, X], P]
+
+// MarshalJSON marshals the entries into an object using JSON.
+func (m DecodableMap[K, I, X, D, P]) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+
+	entries := make(map[K]Decodable[I, X, D], len(m))
+	for k, v := range m {
+		entries[k] = Decodable[I, X, D]{I: v}
+	}
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON does unmarshal the object in data into the entries using JSON.
+// With the Collect policy, the errors of dropped entries are in no particular order.
+func (m *DecodableMap[K, I, X, D, P]) UnmarshalJSON(data []byte) error {
+	var raws map[K]json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return containerError[I, X](codecJSON, err)
+	}
+	if raws == nil {
+		*m = nil
+		return nil
+	}
+
+	var errs elementErrors[I, P]
+	entries := make(DecodableMap[K, I, X, D, P], len(raws))
+	for k, raw := range raws {
+		var d Decodable[I, X, D]
+		if !isJSONNull(raw) {
+			if err := d.UnmarshalJSON(raw); err != nil {
+				if err := errs.handle(k, err); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		entries[k] = d.I
+	}
+	*m = entries
+	return errs.err()
+}
+
+// MarshalMsgpack marshals the entries into a map using msgpack.
+func (m DecodableMap[K, I, X, D, P]) MarshalMsgpack() ([]byte, error) {
+	if m == nil {
+		return msgpack.Marshal(nil)
+	}
+
+	entries := make(map[K]Decodable[I, X, D], len(m))
+	for k, v := range m {
+		entries[k] = Decodable[I, X, D]{I: v}
+	}
+	return msgpack.Marshal(entries)
+}
+
+// EncodeMsgpack encodes the entries into a map to the msgpack encoder.
+func (m DecodableMap[K, I, X, D, P]) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if m == nil {
+		return enc.EncodeNil()
+	}
+
+	entries := make(map[K]Decodable[I, X, D], len(m))
+	for k, v := range m {
+		entries[k] = Decodable[I, X, D]{I: v}
+	}
+	return enc.Encode(entries)
+}
+
+// DecodeMsgpack decodes the next map of the msgpack decoder into the entries.
+func (m *DecodableMap[K, I, X, D, P]) DecodeMsgpack(dec *msgpack.Decoder) error {
+	data, err := dec.DecodeRaw()
+	if err != nil {
+		return containerError[I, X](codecMsgpack, err)
+	}
+	return m.UnmarshalMsgpack(data)
+}
+
+// UnmarshalMsgpack does unmarshal the map in data into the entries using msgpack.
+func (m *DecodableMap[K, I, X, D, P]) UnmarshalMsgpack(data []byte) error {
+	r := bytes.NewReader(data)
+	dec := msgpack.NewDecoder(r)
+	n, err := dec.DecodeMapLen()
+	if err != nil {
+		return containerError[I, X](codecMsgpack, err)
+	}
+	if n < 0 {
+		*m = nil
+		return nil
+	}
+	// every entry takes at least two bytes
+	if n > r.Len()/2 {
+		return containerError[I, X](codecMsgpack, errMsgpackSyntax)
+	}
+
+	var errs elementErrors[I, P]
+	entries := make(DecodableMap[K, I, X, D, P], min(n, maxMsgpackPrealloc))
+	for range n {
+		var k K
+		if err := dec.Decode(&k); err != nil {
+			return containerError[I, X](codecMsgpack, err)
+		}
+		raw, err := dec.DecodeRaw()
+		if err != nil {
+			return containerError[I, X](codecMsgpack, err)
+		}
+
+		var d Decodable[I, X, D]
+		if !isMsgpackNil(raw) {
+			if err := d.UnmarshalMsgpack(raw); err != nil {
+				if err := errs.handle(k, err); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		entries[k] = d.I
+	}
+	*m = entries
+	return errs.err()
+}
@@ -0,0 +1,143 @@
+package ijson_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/Nikkolix/ijson"
+)
+
+func TestDecodableMap_JSON_RoundTrip(t *testing.T) {
+	registerPets(t)
+
+	var pets ijson.RDecodableMap[string, Pet, PetKind, ijson.Strict]
+	require.NoError(t, json.Unmarshal([]byte(`{"rex":{"type":"dog","name":"rex"},"none":null,"tom":{"lives":9,"type":"cat"}}`), &pets))
+	assert.Equal(t, map[string]Pet{"rex": &Dog{Name: "rex"}, "none": nil, "tom": &Cat{Lives: 9}}, map[string]Pet(pets))
+
+	data, err := json.Marshal(pets)
+	require.NoError(t, err)
+	assert.Equal(t, `{"none":null,"rex":{"type":"dog","name":"rex"},"tom":{"type":"cat","lives":9}}`, string(data))
+
+	var byID ijson.RDecodableMap[int, Pet, PetKind, ijson.Strict]
+	require.NoError(t, json.Unmarshal([]byte(`{"1":{"type":"dog"},"2":{"type":"cat"}}`), &byID))
+	assert.Equal(t, map[int]Pet{1: &Dog{}, 2: &Cat{}}, map[int]Pet(byID))
+	data, err = json.Marshal(byID)
+	require.NoError(t, err)
+	assert.Equal(t, `{"1":{"type":"dog","name":""},"2":{"type":"cat","lives":0}}`, string(data))
+
+	type Config struct {
+		Pets ijson.RDecodableMap[string, Pet, PetKind, ijson.Strict] `json:"pets"`
+	}
+	var config Config
+	require.NoError(t, json.Unmarshal([]byte(`{"pets":null}`), &config))
+	assert.Nil(t, config.Pets)
+	data, err = json.Marshal(config)
+	require.NoError(t, err)
+	assert.Equal(t, `{"pets":null}`, string(data))
+
+	assert.Error(t, byID.UnmarshalJSON([]byte(`{"a":{"type":"dog"}}`)))
+	err = byID.UnmarshalJSON([]byte(`[]`))
+	var decodeErr *ijson.DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, ijson.PhaseContainer, decodeErr.Phase)
+}
+
+func TestDecodableMap_JSON_Policies(t *testing.T) {
+	registerPets(t)
+	data := []byte(`{"rex":{"type":"dog"},"nemo":{"type":"fish"}}`)
+
+	var strict ijson.RDecodableMap[string, Pet, PetKind, ijson.Strict]
+	err := json.Unmarshal(data, &strict)
+	var elementErr *ijson.ElementError
+	require.ErrorAs(t, err, &elementErr)
+	assert.Equal(t, "nemo", elementErr.Key)
+	assert.ErrorIs(t, err, ijson.ErrNotRegistered)
+
+	var skip ijson.RDecodableMap[string, Pet, PetKind, ijson.Skip]
+	require.NoError(t, json.Unmarshal(data, &skip))
+	assert.Equal(t, map[string]Pet{"rex": &Dog{}}, map[string]Pet(skip))
+
+	var collect ijson.RDecodableMap[string, Pet, PetKind, ijson.Collect]
+	err = json.Unmarshal(data, &collect)
+	assert.Equal(t, map[string]Pet{"rex": &Dog{}}, map[string]Pet(collect))
+	var elementsErr *ijson.ElementsError
+	require.ErrorAs(t, err, &elementsErr)
+	require.Len(t, elementsErr.Elements, 1)
+	assert.Equal(t, "nemo", elementsErr.Elements[0].Key)
+}
+
+func TestDecodableMap_Msgpack_RoundTrip(t *testing.T) {
+	registerPets(t)
+
+	type Config struct {
+		Pets ijson.RDecodableMap[string, Pet, PetKind, ijson.Strict] `msgpack:"pets"`
+		ByID ijson.RDecodableMap[uint8, Pet, PetKind, ijson.Strict]  `msgpack:"by_id"`
+	}
+	in := Config{
+		Pets: ijson.RDecodableMap[string, Pet, PetKind, ijson.Strict]{"rex": &Dog{Name: "rex"}, "none": nil},
+		ByID: ijson.RDecodableMap[uint8, Pet, PetKind, ijson.Strict]{7: &Cat{Lives: 9}},
+	}
+	data, err := msgpack.Marshal(in)
+	require.NoError(t, err)
+
+	var raw struct {
+		Pets map[string]map[string]any `msgpack:"pets"`
+		ByID map[uint8]map[string]any  `msgpack:"by_id"`
+	}
+	require.NoError(t, msgpack.Unmarshal(data, &raw))
+	assert.Equal(t, "dog", raw.Pets["rex"]["type"])
+	assert.Nil(t, raw.Pets["none"])
+	assert.Equal(t, "cat", raw.ByID[7]["type"])
+
+	var out Config
+	require.NoError(t, msgpack.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+
+	data, err = msgpack.Marshal(Config{})
+	require.NoError(t, err)
+	require.NoError(t, msgpack.Unmarshal(data, &out))
+	assert.Nil(t, out.Pets)
+	assert.Nil(t, out.ByID)
+}
+
+func TestDecodableMap_Msgpack_Policies(t *testing.T) {
+	registerPets(t)
+	data, err := msgpack.Marshal(map[string]any{"nemo": map[string]any{"type": "fish"}})
+	require.NoError(t, err)
+
+	var strict ijson.RDecodableMap[string, Pet, PetKind, ijson.Strict]
+	err = strict.UnmarshalMsgpack(data)
+	assert.Equal(t, "element nemo: msgpack: decide ijson_test.Pet: no factory found in registry[I: ijson_test.Pet, X: ijson_test.PetKind] and X value {fish}", err.Error())
+
+	var skip ijson.RDecodableMap[string, Pet, PetKind, ijson.Skip]
+	require.NoError(t, skip.UnmarshalMsgpack(data))
+	assert.Empty(t, skip)
+
+	var collect ijson.RDecodableMap[string, Pet, PetKind, ijson.Collect]
+	assert.ErrorIs(t, collect.UnmarshalMsgpack(data), ijson.ErrNotRegistered)
+
+	for _, v := range []any{"rex", map[int]any{1: nil}, []byte{0x81, 0xa1, 'k'}} {
+		invalid, ok := v.([]byte)
+		if !ok {
+			invalid, err = msgpack.Marshal(v)
+			require.NoError(t, err)
+		}
+		var decodeErr *ijson.DecodeError
+		require.ErrorAs(t, skip.UnmarshalMsgpack(invalid), &decodeErr, "%v", v)
+		assert.Equal(t, "msgpack", decodeErr.Codec)
+	}
+}
+
+func TestDecodableMap_Msgpack_HugeLength(t *testing.T) {
+	registerPets(t)
+
+	// map32 header claiming 2^32-1 entries without any entry following
+	var m ijson.RDecodableMap[string, Pet, PetKind, ijson.Strict]
+	assert.Error(t, m.UnmarshalMsgpack([]byte{0xdf, 0xff, 0xff, 0xff, 0xff}))
+	// a single key without value cannot hold one entry
+	assert.Error(t, m.UnmarshalMsgpack([]byte{0x81, 0xa1}))
+}
@@ -48,3 +48,32 @@ func TestDecodableXF_Marshal_NilInterface(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, []byte{0xc0}, mb)
 }
+
+type XUntyped struct {
+	Value string `json:"value" msgpack:"value"`
+}
+
+func (x *XUntyped) Kind() string { return "U" }
+
+func TestDecodableXF_Marshal_InjectsDiscriminator(t *testing.T) {
+	ijson.ResetRegistries()
+
+	assert.NoError(t, ijson.RegisterF[XFTestInterface, TestFSelector]("U", func() XFTestInterface { return &XUntyped{} }))
+
+	d := ijson.DecodableF[XFTestInterface, TestFSelector, string]{I: &XUntyped{Value: "abc"}}
+
+	data, err := json.Marshal(d)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"U","value":"abc"}`, string(data))
+
+	var result ijson.DecodableF[XFTestInterface, TestFSelector, string]
+	assert.NoError(t, json.Unmarshal(data, &result))
+	assert.Equal(t, &XUntyped{Value: "abc"}, result.I)
+
+	b, err := msgpack.Marshal(d)
+	assert.NoError(t, err)
+
+	result = ijson.DecodableF[XFTestInterface, TestFSelector, string]{}
+	assert.NoError(t, msgpack.Unmarshal(b, &result))
+	assert.Equal(t, &XUntyped{Value: "abc"}, result.I)
+}
@@ -229,3 +229,74 @@ func TestDecodable_Marshal_EmptyStruct(t *testing.T) {
 	err = msgpack.Unmarshal(msgpackData, &result)
 	assert.NoError(t, err)
 }
+
+type Untyped struct {
+	Name string `json:"name" msgpack:"name"`
+}
+
+func (u *Untyped) GetType() string {
+	return "untyped"
+}
+
+func TestDecodable_MarshalJSON_InjectsDiscriminator(t *testing.T) {
+	ijson.ResetRegistries()
+
+	err := ijson.RegisterT[Untyped, UnmarshalTestInterface](UnmarshalDiscriminator{Type: "untyped"})
+	assert.NoError(t, err)
+
+	decodable := ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]{I: &Untyped{Name: "Fido"}}
+
+	data, err := json.Marshal(decodable)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"untyped","name":"Fido"}`, string(data))
+
+	var result ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]
+	err = json.Unmarshal(data, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, &Untyped{Name: "Fido"}, result.I)
+}
+
+func TestDecodable_MarshalJSON_ReplacesDiscriminatorInPlace(t *testing.T) {
+	ijson.ResetRegistries()
+
+	err := ijson.RegisterT[PersonStruct, UnmarshalTestInterface](UnmarshalDiscriminator{Type: PersonType})
+	assert.NoError(t, err)
+
+	decodable := ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]{I: &PersonStruct{Name: "John", Age: 30}}
+
+	data, err := json.Marshal(decodable)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"John","age":30,"type":"person"}`, string(data))
+}
+
+func TestDecodable_MarshalJSON_UnregisteredTypeUnchanged(t *testing.T) {
+	ijson.ResetRegistries()
+
+	decodable := ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]{I: &Untyped{Name: "Fido"}}
+
+	data, err := json.Marshal(decodable)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"Fido"}`, string(data))
+}
+
+func TestDecodable_MarshalMsgpack_InjectsDiscriminator(t *testing.T) {
+	ijson.ResetRegistries()
+
+	err := ijson.RegisterT[Untyped, UnmarshalTestInterface](UnmarshalDiscriminator{Type: "untyped"})
+	assert.NoError(t, err)
+
+	decodable := ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]{I: &Untyped{Name: "Fido"}}
+
+	data, err := msgpack.Marshal(decodable)
+	assert.NoError(t, err)
+
+	var fields map[string]string
+	err = msgpack.Unmarshal(data, &fields)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"type": "untyped", "name": "Fido"}, fields)
+
+	var result ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]
+	err = msgpack.Unmarshal(data, &result)
+	assert.NoError(t, err)
+	assert.Equal(t, &Untyped{Name: "Fido"}, result.I)
+}
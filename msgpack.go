@@ -0,0 +1,483 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// msgpackField is an entry of a msgpack map with its key and value kept as raw bytes.
+type msgpackField struct {
+	key   []byte
+	value []byte
+}
+
+var errMsgpackSyntax = errors.New("ijson: invalid msgpack")
+
+// splitMsgpackMap splits a msgpack map into its entries without decoding them.
+// It reports false if data is not a single msgpack map.
+func splitMsgpackMap(data []byte) ([]msgpackField, bool) {
+	s := msgpackScanner{data: data}
+	fields, err := s.mapFields(nil)
+	if err != nil || s.pos != len(s.data) {
+		return nil, false
+	}
+	return fields, true
+}
+
+// discriminatorMsgpack returns the entries of the msgpack map data that can be decoded into X.
+// Only the keys of the map are inspected, values are skipped without being decoded.
+// If the keys of X cannot be determined or data is not a map, data is returned unchanged.
+func discriminatorMsgpack[X any](data []byte) ([]byte, error) {
+	keys, ok := msgpackKeys(reflect.TypeFor[X]())
+	if !ok {
+		return data, nil
+	}
+
+	s := msgpackScanner{data: data}
+	fields, err := s.mapFields(keys)
+	if err != nil || s.pos != len(s.data) {
+		return data, nil
+	}
+	return encodeMsgpackMap(fields)
+}
+
+var msgpackKeyCache sync.Map // map[reflect.Type][][]byte
+
+// msgpackKeys returns the map keys msgpack may decode into a value of type t.
+// The result may contain more keys than t actually uses, but never less.
+// It reports false if t is not a struct or decodes itself.
+func msgpackKeys(t reflect.Type) ([][]byte, bool) {
+	if cached, ok := msgpackKeyCache.Load(t); ok {
+		keys := cached.([][]byte)
+		return keys, keys != nil
+	}
+
+	keys := collectMsgpackKeys(t)
+	msgpackKeyCache.Store(t, keys)
+	return keys, keys != nil
+}
+
+var (
+	msgpackCustomDecoderType = reflect.TypeFor[msgpack.CustomDecoder]()
+	msgpackUnmarshalerType   = reflect.TypeFor[msgpack.Unmarshaler]()
+)
+
+func collectMsgpackKeys(t reflect.Type) [][]byte {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	if ptr := reflect.PointerTo(t); ptr.Implements(msgpackCustomDecoderType) || ptr.Implements(msgpackUnmarshalerType) {
+		return nil
+	}
+
+	keys := [][]byte{}
+	for _, f := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(f.Tag.Get("msgpack"), ",")
+		switch name {
+		case "-":
+			continue // unlike encoding/json, msgpack also skips fields tagged "-,"
+		case "":
+			name = f.Name
+		}
+		keys = append(keys, []byte(name))
+	}
+	return keys
+}
+
+// findMsgpackField returns the value of the last entry of fields with the given string key.
+func findMsgpackField(fields []msgpackField, key string) ([]byte, bool) {
+	keys := [][]byte{[]byte(key)}
+	var value []byte
+	found := false
+	for _, f := range fields {
+		if matchMsgpackKey(f.key, keys) {
+			value, found = f.value, true
+		}
+	}
+	return value, found
+}
+
+// isMsgpackNil reports whether data is the msgpack nil value.
+func isMsgpackNil(data []byte) bool {
+	return len(data) == 1 && data[0] == msgpcode.Nil
+}
+
+// maxMsgpackPrealloc caps the number of elements allocated ahead from an untrusted length header,
+// like the msgpack library does.
+const maxMsgpackPrealloc = 1e6
+
+// splitMsgpackArray splits a msgpack array into its raw elements.
+// A nil array is returned as nil, an empty array as empty slice.
+func splitMsgpackArray(data []byte) ([]msgpack.RawMessage, error) {
+	r := bytes.NewReader(data)
+	dec := msgpack.NewDecoder(r)
+	n, err := dec.DecodeArrayLen()
+	if err != nil || n < 0 {
+		return nil, err
+	}
+	// every element takes at least one byte
+	if n > r.Len() {
+		return nil, errMsgpackSyntax
+	}
+
+	raws := make([]msgpack.RawMessage, 0, min(n, maxMsgpackPrealloc))
+	for range n {
+		raw, err := dec.DecodeRaw()
+		if err != nil {
+			return nil, err
+		}
+		raws = append(raws, raw)
+	}
+	return raws, nil
+}
+
+// isMsgpackMap reports whether data starts with a msgpack map.
+func isMsgpackMap(data []byte) bool {
+	c := data[0]
+	return msgpcode.IsFixedMap(c) || c == msgpcode.Map16 || c == msgpcode.Map32
+}
+
+// msgpackMapError returns the error msgpack reports for data that is not a msgpack map.
+func msgpackMapError(data []byte) error {
+	var m map[string]msgpack.RawMessage
+	if err := msgpack.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	return errMsgpackSyntax
+}
+
+// encodeMsgpackWith encodes v with the settings of enc, like its struct tag, and returns the result
+// instead of writing it to the writer of enc.
+func encodeMsgpackWith(enc *msgpack.Encoder, v any) ([]byte, error) {
+	var buf bytes.Buffer
+	w := enc.Writer()
+	// ResetWriter drops the dict of interned strings, WithDict restores it afterward
+	err := enc.WithDict(nil, func(enc *msgpack.Encoder) error {
+		enc.ResetWriter(&buf)
+		defer enc.ResetWriter(w)
+		return enc.Encode(v)
+	})
+	return buf.Bytes(), err
+}
+
+// mergeMsgpack merges the entries of the msgpack map tag into the msgpack map value.
+// Entries of value that also exist in tag are replaced in place, or merged if both are maps,
+// all other entries of tag are prepended.
+// If either input is not a map, value is returned unchanged.
+func mergeMsgpack(tag, value []byte) ([]byte, error) {
+	tagFields, ok := splitMsgpackMap(tag)
+	if !ok {
+		return value, nil
+	}
+	valueFields, ok := splitMsgpackMap(value)
+	if !ok {
+		return value, nil
+	}
+
+	merged := make([]msgpackField, 0, len(tagFields)+len(valueFields))
+	used := make([]bool, len(tagFields))
+	for _, vf := range valueFields {
+		for i, tf := range tagFields {
+			if bytes.Equal(tf.key, vf.key) {
+				if isMsgpackMap(tf.value) && isMsgpackMap(vf.value) {
+					value, err := mergeMsgpack(tf.value, vf.value)
+					if err != nil {
+						return nil, err
+					}
+					vf.value = value
+				} else {
+					vf.value = tf.value
+				}
+				used[i] = true
+				break
+			}
+		}
+		merged = append(merged, vf)
+	}
+
+	prefix := make([]msgpackField, 0, len(tagFields))
+	for i, tf := range tagFields {
+		if !used[i] {
+			prefix = append(prefix, tf)
+		}
+	}
+	return encodeMsgpackMap(append(prefix, merged...))
+}
+
+// encodeMsgpackMap writes the fields as a msgpack map.
+func encodeMsgpackMap(fields []msgpackField) ([]byte, error) {
+	size := 5
+	for _, f := range fields {
+		size += len(f.key) + len(f.value)
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, size))
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+	enc.Reset(buf)
+
+	if err := enc.EncodeMapLen(len(fields)); err != nil {
+		return nil, err
+	}
+	for _, f := range fields {
+		buf.Write(f.key)
+		buf.Write(f.value)
+	}
+	return buf.Bytes(), nil
+}
+
+// maxMsgpackDepth limits the nesting of scanned msgpack values.
+const maxMsgpackDepth = 10000
+
+// msgpackScanner is a minimal msgpack scanner that only records value boundaries.
+type msgpackScanner struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+// mapFields scans a msgpack map and returns its entries.
+// If keys is set, only entries with a string key equal to one of keys are returned.
+func (s *msgpackScanner) mapFields(keys [][]byte) ([]msgpackField, error) {
+	n, err := s.mapLen()
+	if err != nil {
+		return nil, err
+	}
+
+	var fields []msgpackField
+	for range n {
+		key, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		value, err := s.value()
+		if err != nil {
+			return nil, err
+		}
+		if keys == nil || matchMsgpackKey(key, keys) {
+			fields = append(fields, msgpackField{key: key, value: value})
+		}
+	}
+	return fields, nil
+}
+
+// matchMsgpackKey reports whether the raw msgpack value key is a string equal to one of keys.
+func matchMsgpackKey(key []byte, keys [][]byte) bool {
+	s := msgpackScanner{data: key}
+	name, err := s.str()
+	if err != nil {
+		return false
+	}
+
+	for _, k := range keys {
+		if bytes.Equal(name, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *msgpackScanner) mapLen() (int, error) {
+	c, err := s.code()
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case msgpcode.IsFixedMap(c):
+		return int(c & msgpcode.FixedMapMask), nil
+	case c == msgpcode.Map16:
+		n, err := s.uint(2)
+		return int(n), err
+	case c == msgpcode.Map32:
+		n, err := s.uint(4)
+		return int(n), err
+	}
+	return 0, errMsgpackSyntax
+}
+
+// str returns the content of a msgpack string or binary, as the msgpack library accepts both as map keys.
+func (s *msgpackScanner) str() ([]byte, error) {
+	c, err := s.code()
+	if err != nil {
+		return nil, err
+	}
+
+	var n uint64
+	switch {
+	case msgpcode.IsFixedString(c):
+		n = uint64(c & msgpcode.FixedStrMask)
+	case c == msgpcode.Str8, c == msgpcode.Bin8:
+		n, err = s.uint(1)
+	case c == msgpcode.Str16, c == msgpcode.Bin16:
+		n, err = s.uint(2)
+	case c == msgpcode.Str32, c == msgpcode.Bin32:
+		n, err = s.uint(4)
+	default:
+		return nil, errMsgpackSyntax
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	start := s.pos
+	if err := s.skip(n); err != nil {
+		return nil, err
+	}
+	return s.data[start:s.pos], nil
+}
+
+// value scans a single msgpack value and returns its raw bytes.
+func (s *msgpackScanner) value() ([]byte, error) {
+	start := s.pos
+	c, err := s.code()
+	if err != nil {
+		return nil, err
+	}
+
+	var n uint64
+	switch {
+	case msgpcode.IsFixedNum(c), c == msgpcode.Nil, c == msgpcode.False, c == msgpcode.True:
+	case msgpcode.IsFixedString(c):
+		err = s.skip(uint64(c & msgpcode.FixedStrMask))
+	case msgpcode.IsFixedMap(c):
+		err = s.values(2 * uint64(c&msgpcode.FixedMapMask))
+	case msgpcode.IsFixedArray(c):
+		err = s.values(uint64(c & msgpcode.FixedArrayMask))
+	case c == msgpcode.Uint8, c == msgpcode.Int8:
+		err = s.skip(1)
+	case c == msgpcode.Uint16, c == msgpcode.Int16:
+		err = s.skip(2)
+	case c == msgpcode.Uint32, c == msgpcode.Int32, c == msgpcode.Float:
+		err = s.skip(4)
+	case c == msgpcode.Uint64, c == msgpcode.Int64, c == msgpcode.Double:
+		err = s.skip(8)
+	case c == msgpcode.Str8, c == msgpcode.Bin8:
+		n, err = s.uint(1)
+		if err == nil {
+			err = s.skip(n)
+		}
+	case c == msgpcode.Str16, c == msgpcode.Bin16:
+		n, err = s.uint(2)
+		if err == nil {
+			err = s.skip(n)
+		}
+	case c == msgpcode.Str32, c == msgpcode.Bin32:
+		n, err = s.uint(4)
+		if err == nil {
+			err = s.skip(n)
+		}
+	case c == msgpcode.Array16:
+		n, err = s.uint(2)
+		if err == nil {
+			err = s.values(n)
+		}
+	case c == msgpcode.Array32:
+		n, err = s.uint(4)
+		if err == nil {
+			err = s.values(n)
+		}
+	case c == msgpcode.Map16:
+		n, err = s.uint(2)
+		if err == nil {
+			err = s.values(2 * n)
+		}
+	case c == msgpcode.Map32:
+		n, err = s.uint(4)
+		if err == nil {
+			err = s.values(2 * n)
+		}
+	case c == msgpcode.FixExt1:
+		err = s.skip(2)
+	case c == msgpcode.FixExt2:
+		err = s.skip(3)
+	case c == msgpcode.FixExt4:
+		err = s.skip(5)
+	case c == msgpcode.FixExt8:
+		err = s.skip(9)
+	case c == msgpcode.FixExt16:
+		err = s.skip(17)
+	case c == msgpcode.Ext8:
+		n, err = s.uint(1)
+		if err == nil {
+			err = s.skip(n + 1)
+		}
+	case c == msgpcode.Ext16:
+		n, err = s.uint(2)
+		if err == nil {
+			err = s.skip(n + 1)
+		}
+	case c == msgpcode.Ext32:
+		n, err = s.uint(4)
+		if err == nil {
+			err = s.skip(n + 1)
+		}
+	default:
+		err = errMsgpackSyntax
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.data[start:s.pos], nil
+}
+
+func (s *msgpackScanner) values(n uint64) error {
+	s.depth++
+	if s.depth > maxMsgpackDepth {
+		return errMsgpackSyntax
+	}
+
+	for range n {
+		if _, err := s.value(); err != nil {
+			return err
+		}
+	}
+	s.depth--
+	return nil
+}
+
+func (s *msgpackScanner) code() (byte, error) {
+	if s.pos >= len(s.data) {
+		return 0, errMsgpackSyntax
+	}
+	c := s.data[s.pos]
+	s.pos++
+	return c, nil
+}
+
+// uint reads a big endian unsigned integer of size bytes.
+func (s *msgpackScanner) uint(size int) (uint64, error) {
+	if len(s.data)-s.pos < size {
+		return 0, errMsgpackSyntax
+	}
+
+	b := s.data[s.pos : s.pos+size]
+	s.pos += size
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	default:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+}
+
+func (s *msgpackScanner) skip(n uint64) error {
+	if uint64(len(s.data)-s.pos) < n {
+		return errMsgpackSyntax
+	}
+	s.pos += int(n)
+	return nil
+}
@@ -0,0 +1,521 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	_ json.Marshaler      = MValue[fieldsType, [2]string]{}
+	_ json.Unmarshaler    = &MValue[fieldsType, [2]string]{}
+	_ msgpack.Marshaler   = MValue[fieldsType, [2]string]{}
+	_ msgpack.Unmarshaler = &MValue[fieldsType, [2]string]{}
+	_ yaml.Marshaler      = MValue[fieldsType, [2]string]{}
+	_ yaml.Unmarshaler    = &MValue[fieldsType, [2]string]{}
+	_ cbor.Marshaler      = MValue[fieldsType, [2]string]{}
+	_ cbor.Unmarshaler    = &MValue[fieldsType, [2]string]{}
+	_ bson.Marshaler      = MValue[fieldsType, [2]string]{}
+	_ bson.Unmarshaler    = &MValue[fieldsType, [2]string]{}
+	_ xml.Marshaler       = MValue[fieldsType, [2]string]{}
+	_ xml.Unmarshaler     = &MValue[fieldsType, [2]string]{}
+
+	_ Discriminator[any, MValue[fieldsType, [2]string]] = MDecider[any, fieldsType, [2]string]{}
+)
+
+// fieldsType is a multi-field selector used for compile time assertions.
+type fieldsType struct{}
+
+func (fieldsType) FieldNames() []string { return []string{"apiVersion", "kind"} }
+
+// MSelector is an interface for types that name the fields of a composite discriminator.
+// Each field name may be a path as described for FSelector.
+type MSelector interface {
+	FieldNames() []string
+	~struct{}
+}
+
+// MValue is the discriminator of a DecodableM: the values of the fields named by M as tuple X.
+// X must be an array or a struct of exported fields with one element per field name, in the same order,
+// e.g. [2]string or struct{ APIVersion, Kind string }.
+// Decoding an object into it extracts only these fields and skips all other fields without decoding them.
+type MValue[M MSelector, X comparable] struct {
+	X       X        // The tuple of field values
+	Missing []string // The names of the fields that are not present, in the order of M
+}
+
+// String returns the field names and values, as used in error messages.
+func (v MValue[M, X]) String() string {
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return fmt.Sprint(v.X)
+	}
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		if slices.Contains(v.Missing, name) {
+			parts[i] = name + "=<missing>"
+		} else {
+			parts[i] = fmt.Sprintf("%s=%v", name, elems[i])
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// MarshalJSON marshals the present fields as a JSON object.
+func (v MValue[M, X]) MarshalJSON() ([]byte, error) {
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return nil, err
+	}
+
+	data := []byte("{}")
+	for i, name := range names {
+		if slices.Contains(v.Missing, name) {
+			continue
+		}
+
+		value, err := json.Marshal(elems[i].Interface())
+		if err != nil {
+			return nil, err
+		}
+		field, err := nestJSON(value, fieldPath(name))
+		if err != nil {
+			return nil, err
+		}
+		data = mergeJSON(data, field)
+	}
+	return data, nil
+}
+
+// UnmarshalJSON decodes the fields named by M from the JSON object in data.
+// Fields that are not present are listed in Missing.
+func (v *MValue[M, X]) UnmarshalJSON(data []byte) error {
+	*v = MValue[M, X]{}
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return err
+	}
+
+	values := make([][]byte, len(names))
+	if !isJSONNull(data) {
+		values, err = lookupJSONPaths(data, fieldPaths(names))
+		if err != nil {
+			return err
+		}
+	}
+	for i, value := range values {
+		if value == nil {
+			v.Missing = append(v.Missing, names[i])
+			continue
+		}
+
+		if err := json.Unmarshal(value, elems[i].Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalMsgpack marshals the present fields as a msgpack map.
+func (v MValue[M, X]) MarshalMsgpack() ([]byte, error) {
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := encodeMsgpackMap(nil)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		if slices.Contains(v.Missing, name) {
+			continue
+		}
+
+		value, err := msgpack.Marshal(elems[i].Interface())
+		if err != nil {
+			return nil, err
+		}
+		field, err := nestMsgpack(value, fieldPath(name))
+		if err != nil {
+			return nil, err
+		}
+		data, err = mergeMsgpack(data, field)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// UnmarshalMsgpack decodes the fields named by M from the msgpack map in data.
+// Fields that are not present are listed in Missing.
+func (v *MValue[M, X]) UnmarshalMsgpack(data []byte) error {
+	*v = MValue[M, X]{}
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return err
+	}
+
+	values := make([][]byte, len(names))
+	if !isMsgpackNil(data) {
+		values, err = lookupMsgpackPaths(data, fieldPaths(names))
+		if err != nil {
+			return err
+		}
+	}
+	for i, value := range values {
+		if value == nil {
+			v.Missing = append(v.Missing, names[i])
+			continue
+		}
+
+		if err := msgpack.Unmarshal(value, elems[i].Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalYAML marshals the present fields as a YAML mapping.
+func (v MValue[M, X]) MarshalYAML() (any, error) {
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return nil, err
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i, name := range names {
+		if slices.Contains(v.Missing, name) {
+			continue
+		}
+
+		var value yaml.Node
+		if err := value.Encode(elems[i].Interface()); err != nil {
+			return nil, err
+		}
+		node = mergeYAML(node, nestYAML(&value, fieldPath(name)))
+	}
+	return node, nil
+}
+
+// UnmarshalYAML decodes the fields named by M from the YAML mapping node.
+// Fields that are not present are listed in Missing.
+func (v *MValue[M, X]) UnmarshalYAML(node *yaml.Node) error {
+	*v = MValue[M, X]{}
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return err
+	}
+
+	values := make([]*yaml.Node, len(names))
+	if !isYAMLNull(node) {
+		values, err = lookupYAMLPaths(node, fieldPaths(names))
+		if err != nil {
+			return err
+		}
+	}
+	for i, value := range values {
+		if value == nil {
+			v.Missing = append(v.Missing, names[i])
+			continue
+		}
+
+		if err := value.Decode(elems[i].Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalCBOR marshals the present fields as a CBOR map.
+func (v MValue[M, X]) MarshalCBOR() ([]byte, error) {
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return nil, err
+	}
+
+	data := encodeCBORMap(nil)
+	for i, name := range names {
+		if slices.Contains(v.Missing, name) {
+			continue
+		}
+
+		value, err := cbor.Marshal(elems[i].Interface())
+		if err != nil {
+			return nil, err
+		}
+		data = mergeCBOR(data, nestCBOR(value, fieldPath(name)))
+	}
+	return data, nil
+}
+
+// UnmarshalCBOR decodes the fields named by M from the CBOR map in data.
+// Fields that are not present are listed in Missing.
+func (v *MValue[M, X]) UnmarshalCBOR(data []byte) error {
+	*v = MValue[M, X]{}
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return err
+	}
+
+	values := make([][]byte, len(names))
+	if !isCBORNull(data) {
+		values, err = lookupCBORPaths(data, fieldPaths(names))
+		if err != nil {
+			return err
+		}
+	}
+	for i, value := range values {
+		if value == nil {
+			v.Missing = append(v.Missing, names[i])
+			continue
+		}
+
+		if err := cbor.Unmarshal(value, elems[i].Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalBSON marshals the present fields as a BSON document.
+func (v MValue[M, X]) MarshalBSON() ([]byte, error) {
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := bson.Marshal(bson.D{})
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		if slices.Contains(v.Missing, name) {
+			continue
+		}
+
+		t, value, err := bson.MarshalValue(elems[i].Interface())
+		if err != nil {
+			return nil, err
+		}
+		field, err := nestBSON(bson.RawValue{Type: t, Value: value}, fieldPath(name))
+		if err != nil {
+			return nil, err
+		}
+		data, err = mergeBSON(data, field)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// UnmarshalBSON decodes the fields named by M from the BSON document in data.
+// Fields that are not present are listed in Missing.
+func (v *MValue[M, X]) UnmarshalBSON(data []byte) error {
+	*v = MValue[M, X]{}
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return err
+	}
+
+	values, err := lookupBSONPaths(data, fieldPaths(names))
+	if err != nil {
+		return err
+	}
+	for i, value := range values {
+		if value.Type == 0 {
+			v.Missing = append(v.Missing, names[i])
+			continue
+		}
+
+		if err := value.Unmarshal(elems[i].Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MarshalXML encodes the present fields as element named by start, see FValue.MarshalXML.
+func (v MValue[M, X]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return err
+	}
+
+	n := &xmlNode{start: start}
+	for i, name := range names {
+		if slices.Contains(v.Missing, name) {
+			continue
+		}
+
+		field, err := nestXML(start, fieldPath(name), elems[i].Interface())
+		if err != nil {
+			return err
+		}
+		n = mergeXML(n, field)
+	}
+	return writeXML(e, n)
+}
+
+// UnmarshalXML decodes the fields named by M from the element opened by start, see FValue.UnmarshalXML.
+// Fields that are not present are listed in Missing.
+func (v *MValue[M, X]) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	*v = MValue[M, X]{}
+	names := (*new(M)).FieldNames()
+	elems, err := tupleElems(reflect.ValueOf(&v.X).Elem(), len(names))
+	if err != nil {
+		return err
+	}
+
+	n, err := readXML(dec, start)
+	if err != nil {
+		return err
+	}
+	for i, name := range names {
+		value, ok := lookupXML(n, fieldPath(name))
+		if !ok {
+			v.Missing = append(v.Missing, name)
+			continue
+		}
+
+		if err := decodeXML(value, elems[i].Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldPaths returns the path of each field name.
+func fieldPaths(names []string) [][]string {
+	paths := make([][]string, len(names))
+	for i, name := range names {
+		paths[i] = fieldPath(name)
+	}
+	return paths
+}
+
+// tupleElems returns the elements of the addressable tuple v,
+// which must be an array of length n or a struct of n exported fields.
+func tupleElems(v reflect.Value, n int) ([]reflect.Value, error) {
+	t := v.Type()
+	switch {
+	case t.Kind() == reflect.Array && t.Len() == n:
+		elems := make([]reflect.Value, n)
+		for i := range elems {
+			elems[i] = v.Index(i)
+		}
+		return elems, nil
+	case t.Kind() == reflect.Struct && t.NumField() == n:
+		elems := make([]reflect.Value, n)
+		for i := range elems {
+			if !t.Field(i).IsExported() {
+				return nil, &FactoryError{Err: ErrTupleType, Type: t}
+			}
+			elems[i] = v.Field(i)
+		}
+		return elems, nil
+	}
+	return nil, &FactoryError{Err: ErrTupleType, Type: t}
+}
+
+// checkTuple returns a FactoryError if X is not a tuple for the field names of M, see MValue.
+func checkTuple[I any, M MSelector, X any]() error {
+	if _, err := tupleElems(reflect.ValueOf(new(X)).Elem(), len((*new(M)).FieldNames())); err != nil {
+		return &FactoryError{Err: ErrTupleType, Interface: reflect.TypeFor[I](), Type: reflect.TypeFor[X]()}
+	}
+	return nil
+}
+
+// RegisterM registers a factory function for interface I, tuple X and multi-field selector M
+// in the default registry.
+func RegisterM[I any, M MSelector, X comparable](x X, factory func() I) error {
+	return RegisterMIn[I, M](defaultRegistry, x, factory)
+}
+
+// RegisterMIn registers a factory function for interface I, tuple X and multi-field selector M
+// in registry r.
+func RegisterMIn[I any, M MSelector, X comparable](r *Registry, x X, factory func() I) error {
+	if err := checkTuple[I, M, X](); err != nil {
+		return err
+	}
+
+	return registerIn[I, M](r, x, factory, reflect.TypeFor[M]())
+}
+
+// RegisterDefaultM registers a fallback factory for interface I, tuple X and multi-field selector M
+// in the default registry. It is used for tuples without a registered factory.
+func RegisterDefaultM[I any, M MSelector, X comparable](factory func(x X) I) error {
+	return RegisterDefaultMIn[I, M](defaultRegistry, factory)
+}
+
+// RegisterDefaultMIn registers a fallback factory for interface I, tuple X and multi-field selector M
+// in registry r. It is used for tuples without a registered factory.
+func RegisterDefaultMIn[I any, M MSelector, X comparable](r *Registry, factory func(x X) I) error {
+	if err := checkTuple[I, M, X](); err != nil {
+		return err
+	}
+
+	return registerDefaultIn[I, M](r, factory, reflect.TypeFor[M]())
+}
+
+// ScopedMDecider resolves a concrete type from the registry selected by S
+// based on a composite discriminator of several fields.
+type ScopedMDecider[I any, M MSelector, X comparable, S Scope] struct{}
+
+// MDecider resolves a concrete type from the default registry based on a composite discriminator.
+type MDecider[I any, M MSelector, X comparable] = ScopedMDecider[I, M, X, DefaultScope]
+
+// Decide returns a new instance of I from the registry for the tuple of field values.
+// If no factory is registered for the tuple, the fallback registered with RegisterDefaultM is used.
+// If a field is missing, the returned MissingFieldError names the first missing field.
+func (ScopedMDecider[I, M, X, S]) Decide(v MValue[M, X]) (I, error) {
+	if len(v.Missing) > 0 {
+		var i I
+		return i, &MissingFieldError{Interface: reflect.TypeFor[I](), Selector: reflect.TypeFor[M](), Field: v.Missing[0]}
+	}
+
+	i, ok := loadTable[I, M, X]((*new(S)).Registry()).decide(v.X)
+	if !ok {
+		return i, &NotRegisteredError{Interface: reflect.TypeFor[I](), Selector: reflect.TypeFor[M](), Discriminator: reflect.TypeFor[X](), Value: v.X}
+	}
+	return i, nil
+}
+
+// Discriminate returns the tuple registered for the concrete type of i.
+func (ScopedMDecider[I, M, X, S]) Discriminate(i I) (MValue[M, X], bool) {
+	x, ok := loadTable[I, M, X]((*new(S)).Registry()).discriminator(reflect.TypeOf(i))
+	return MValue[M, X]{X: x}, ok
+}
+
+// DecodableM is a type alias for Decodable using MDecider.
+type DecodableM[I any, M MSelector, X comparable] = Decodable[I, MValue[M, X], MDecider[I, M, X]]
+
+// SDecodableM is a type alias for Decodable using ScopedMDecider.
+type SDecodableM[I any, M MSelector, X comparable, S Scope] = Decodable[I, MValue[M, X], ScopedMDecider[I, M, X, S]]
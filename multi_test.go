@@ -0,0 +1,221 @@
+package ijson_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/Nikkolix/ijson"
+)
+
+type APIKind struct{}
+
+func (APIKind) FieldNames() []string { return []string{"apiVersion", "kind"} }
+
+type GVK struct {
+	Version string
+	Kind    string
+}
+
+type Object interface {
+	Name() string
+}
+
+type Deployment struct {
+	Metadata struct {
+		Name string `json:"name" msgpack:"name"`
+	} `json:"metadata" msgpack:"metadata"`
+	Replicas int `json:"replicas" msgpack:"replicas"`
+}
+
+func (d *Deployment) Name() string { return d.Metadata.Name }
+
+type DeploymentV1Beta1 struct {
+	Deployment
+}
+
+type ConfigMap struct {
+	Metadata struct {
+		Name string `json:"name" msgpack:"name"`
+	} `json:"metadata" msgpack:"metadata"`
+	Data map[string]string `json:"data" msgpack:"data"`
+}
+
+func (c *ConfigMap) Name() string { return c.Metadata.Name }
+
+func registerObjects(t *testing.T) {
+	t.Helper()
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterM[Object, APIKind]([2]string{"apps/v1", "Deployment"}, func() Object { return &Deployment{} }))
+	require.NoError(t, ijson.RegisterM[Object, APIKind]([2]string{"apps/v1beta1", "Deployment"}, func() Object { return &DeploymentV1Beta1{} }))
+	require.NoError(t, ijson.RegisterM[Object, APIKind]([2]string{"v1", "ConfigMap"}, func() Object { return &ConfigMap{} }))
+}
+
+func TestRegisterM_Errors(t *testing.T) {
+	registerObjects(t)
+
+	err := ijson.RegisterM[Object, APIKind]([2]string{"v1", "ConfigMap"}, func() Object { return &ConfigMap{} })
+	assert.ErrorIs(t, err, ijson.ErrDuplicate)
+	assert.EqualError(t, err, "value [v1 ConfigMap] already registered for registry[I: ijson_test.Object, F: ijson_test.APIKind, X: [2]string]")
+
+	err = ijson.RegisterM[Object, APIKind]([3]string{}, func() Object { return &ConfigMap{} })
+	assert.ErrorIs(t, err, ijson.ErrTupleType)
+	assert.EqualError(t, err, "tuple type [3]string must be an array or struct of exported fields with one element per field name")
+	var factoryErr *ijson.FactoryError
+	require.ErrorAs(t, err, &factoryErr)
+	assert.Equal(t, reflect.TypeFor[Object](), factoryErr.Interface)
+	assert.Equal(t, reflect.TypeFor[[3]string](), factoryErr.Type)
+
+	err = ijson.RegisterM[Object, APIKind](struct{ a, b string }{}, func() Object { return &ConfigMap{} })
+	assert.ErrorIs(t, err, ijson.ErrTupleType)
+	assert.EqualError(t, err, "tuple type struct { a string; b string } must be an array or struct of exported fields with one element per field name")
+
+	err = ijson.RegisterM[any, APIKind](GVK{}, func() any { return ConfigMap{} })
+	assert.ErrorIs(t, err, ijson.ErrNonPointerFactory)
+}
+
+func TestDecodableM_UnmarshalJSON(t *testing.T) {
+	registerObjects(t)
+
+	data := []byte(`[
+		{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web"},"replicas":2},
+		{"kind":"Deployment","apiVersion":"apps/v1beta1","metadata":{"name":"old"}},
+		{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cfg"},"data":{"a":"b"}}
+	]`)
+	var out []ijson.DecodableM[Object, APIKind, [2]string]
+	require.NoError(t, json.Unmarshal(data, &out))
+	require.Len(t, out, 3)
+	assert.IsType(t, &Deployment{}, out[0].I)
+	assert.Equal(t, 2, out[0].I.(*Deployment).Replicas)
+	assert.IsType(t, &DeploymentV1Beta1{}, out[1].I)
+	assert.Equal(t, "old", out[1].I.Name())
+	assert.Equal(t, map[string]string{"a": "b"}, out[2].I.(*ConfigMap).Data)
+
+	again, err := json.Marshal(out[1])
+	require.NoError(t, err)
+	assert.Equal(t, `{"apiVersion":"apps/v1beta1","kind":"Deployment","metadata":{"name":"old"},"replicas":0}`, string(again))
+}
+
+func TestDecodableM_Msgpack(t *testing.T) {
+	registerObjects(t)
+
+	cm := &ConfigMap{Data: map[string]string{"k": "v"}}
+	cm.Metadata.Name = "cfg"
+	in := []ijson.DecodableM[Object, APIKind, [2]string]{{I: cm}, {I: &DeploymentV1Beta1{}}}
+	data, err := msgpack.Marshal(in)
+	require.NoError(t, err)
+
+	var out []ijson.DecodableM[Object, APIKind, [2]string]
+	require.NoError(t, msgpack.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+
+	data, err = msgpack.Marshal(map[string]string{"apiVersion": "v1"})
+	require.NoError(t, err)
+	var d ijson.DecodableM[Object, APIKind, [2]string]
+	err = msgpack.Unmarshal(data, &d)
+	var missing *ijson.MissingFieldError
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, "kind", missing.Field)
+	assert.EqualError(t, err, "msgpack: decide ijson_test.Object for X value apiVersion=v1,kind=<missing>: discriminator field kind not found")
+
+	assert.Error(t, msgpack.Unmarshal([]byte{0x91, 0xc0}, &d))
+}
+
+func TestDecodableM_Errors(t *testing.T) {
+	registerObjects(t)
+
+	var d ijson.DecodableM[Object, APIKind, [2]string]
+	err := json.Unmarshal([]byte(`{"metadata":{}}`), &d)
+	var missing *ijson.MissingFieldError
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, "apiVersion", missing.Field)
+
+	err = json.Unmarshal([]byte(`{"apiVersion":"v2","kind":"ConfigMap"}`), &d)
+	var notRegistered *ijson.NotRegisteredError
+	require.ErrorAs(t, err, &notRegistered)
+	assert.Equal(t, [2]string{"v2", "ConfigMap"}, notRegistered.Value)
+
+	err = json.Unmarshal([]byte(`{"apiVersion":1,"kind":"ConfigMap"}`), &d)
+	var decodeErr *ijson.DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, ijson.PhaseDiscriminator, decodeErr.Phase)
+}
+
+func TestRegisterDefaultM(t *testing.T) {
+	registerObjects(t)
+	require.NoError(t, ijson.RegisterDefaultM[Object, APIKind](func(x [2]string) Object { return &ConfigMap{} }))
+
+	err := ijson.RegisterDefaultM[Object, APIKind](func(x [2]string) Object { return &ConfigMap{} })
+	assert.ErrorIs(t, err, ijson.ErrDuplicate)
+	err = ijson.RegisterDefaultM[Object, APIKind](func(x [3]string) Object { return &ConfigMap{} })
+	assert.ErrorIs(t, err, ijson.ErrTupleType)
+
+	var d ijson.DecodableM[Object, APIKind, [2]string]
+	require.NoError(t, json.Unmarshal([]byte(`{"apiVersion":"v2","kind":"Secret","metadata":{"name":"s"}}`), &d))
+	assert.IsType(t, &ConfigMap{}, d.I)
+	assert.Equal(t, "s", d.I.Name())
+
+	require.NoError(t, json.Unmarshal([]byte(`{"apiVersion":"apps/v1","kind":"Deployment"}`), &d))
+	assert.IsType(t, &Deployment{}, d.I, "registered tuples take precedence over the fallback")
+}
+
+type NestedGVK struct{}
+
+func (NestedGVK) FieldNames() []string { return []string{"/meta/group", "/meta/version", "kind"} }
+
+type GroupVersionKind struct {
+	Group, Version, Kind string
+}
+
+func TestDecodableM_StructTuple(t *testing.T) {
+	ijson.ResetRegistries()
+	gvk := GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	require.NoError(t, ijson.RegisterM[Object, NestedGVK](gvk, func() Object { return &Deployment{} }))
+
+	var d ijson.DecodableM[Object, NestedGVK, GroupVersionKind]
+	require.NoError(t, json.Unmarshal([]byte(`{"kind":"Deployment","meta":{"version":"v1","group":"apps"},"replicas":1}`), &d))
+	assert.Equal(t, 1, d.I.(*Deployment).Replicas)
+
+	data, err := json.Marshal(d)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"meta":{"group":"apps","version":"v1"},"kind":"Deployment","metadata":{"name":""},"replicas":1}`, string(data))
+
+	packed, err := msgpack.Marshal(d)
+	require.NoError(t, err)
+	d.I = nil
+	require.NoError(t, msgpack.Unmarshal(packed, &d))
+	assert.Equal(t, 1, d.I.(*Deployment).Replicas)
+}
+
+func TestMValue(t *testing.T) {
+	type tuple = ijson.MValue[APIKind, GVK]
+
+	var v tuple
+	require.NoError(t, json.Unmarshal([]byte(`null`), &v))
+	assert.Equal(t, []string{"apiVersion", "kind"}, v.Missing)
+	assert.Equal(t, "apiVersion=<missing>,kind=<missing>", v.String())
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(data))
+
+	require.NoError(t, v.UnmarshalMsgpack([]byte{0xc0}))
+	assert.Equal(t, []string{"apiVersion", "kind"}, v.Missing)
+
+	assert.Error(t, json.Unmarshal([]byte(`[]`), &v))
+	assert.Error(t, json.Unmarshal([]byte(`{"kind":1}`), &v))
+
+	type invalid = ijson.MValue[APIKind, [1]int]
+	var i invalid
+	assert.Error(t, json.Unmarshal([]byte(`{}`), &i))
+	assert.Error(t, msgpack.Unmarshal([]byte{0x80}, &i))
+	_, err = json.Marshal(i)
+	assert.Error(t, err)
+	_, err = msgpack.Marshal(i)
+	assert.Error(t, err)
+	assert.Equal(t, "[0]", i.String())
+}
@@ -0,0 +1,335 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"maps"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// Registry holds the factories registered for interface and discriminator types.
+// Registrations for the same interface and discriminator types in different registries do not interfere.
+// The zero value is an empty registry ready to use.
+// A Registry must not be copied after first use.
+type Registry struct {
+	mutex sync.RWMutex
+	// frozen holds tables once the registry is frozen; they are read without locking.
+	frozen atomic.Pointer[map[reflect.Type]any]
+	// tables holds a *table[I, X] for each tableKey[I, S, X] and the []func() I for each candidatesKey[I],
+	// keyed by the type of the key. Values are never modified in place, so snapshots share them.
+	tables map[reflect.Type]any
+}
+
+// NewRegistry returns a new, empty registry.
+func NewRegistry() *Registry {
+	return &Registry{tables: map[reflect.Type]any{}}
+}
+
+// Reset clears all registered types and unfreezes r.
+func (r *Registry) Reset() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.frozen.Store(nil)
+	r.tables = map[reflect.Type]any{}
+}
+
+// Freeze makes r read-only. Registering, replacing and unregistering afterward fail with ErrFrozen,
+// while lookups no longer take a lock. Freeze is typically called once at the end of program initialization.
+// Reset and Restore unfreeze r.
+func (r *Registry) Freeze() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	tables := r.tables
+	r.frozen.Store(&tables)
+}
+
+// Frozen reports whether r is frozen.
+func (r *Registry) Frozen() bool {
+	return r.frozen.Load() != nil
+}
+
+// Snapshot is a copy of the registrations of a registry at one point in time.
+// The zero value is an empty snapshot.
+type Snapshot struct {
+	tables map[reflect.Type]any
+}
+
+// Snapshot returns a copy of the current registrations of r.
+// Later changes to r do not affect the snapshot.
+func (r *Registry) Snapshot() Snapshot {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return Snapshot{tables: maps.Clone(r.tables)}
+}
+
+// Restore replaces all registrations of r with the registrations of snapshot s and unfreezes r.
+// A snapshot can be restored any number of times.
+func (r *Registry) Restore(s Snapshot) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.frozen.Store(nil)
+	r.tables = maps.Clone(s.tables)
+}
+
+// Clone returns a new registry holding a copy of the registrations of r.
+// The clone is not frozen.
+func (r *Registry) Clone() *Registry {
+	c := NewRegistry()
+	c.Restore(r.Snapshot())
+	return c
+}
+
+// get returns the value stored under key or nil. Frozen registries are read without locking.
+func (r *Registry) get(key reflect.Type) any {
+	if tables := r.frozen.Load(); tables != nil {
+		return (*tables)[key]
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.tables[key]
+}
+
+// set stores value under key. The caller must hold the write lock.
+func (r *Registry) set(key reflect.Type, value any) {
+	if r.tables == nil {
+		r.tables = map[reflect.Type]any{}
+	}
+	r.tables[key] = value
+}
+
+var defaultRegistry = NewRegistry()
+
+// DefaultRegistry returns the registry used by the package level functions,
+// RegistryDecider and FDecider.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+// Scope is an interface for types that select the registry used by a scoped decider.
+// It binds decoding to a registry at the type level, so nested Decodable values use it as well.
+type Scope interface {
+	Registry() *Registry
+	~struct{}
+}
+
+// DefaultScope selects the default registry.
+type DefaultScope struct{}
+
+// Registry returns the default registry.
+func (DefaultScope) Registry() *Registry {
+	return defaultRegistry
+}
+
+// IsolatedScope selects a registry of its own for each type T, created on first use as a clone
+// of the default registry. A test that declares its own T can register into and decode from
+// this copy without affecting other tests, so it can run in parallel with them.
+// RDecodable and the other default-scope types keep using the default registry;
+// use SDecodable and the other scoped types with IsolatedScope[T] instead.
+type IsolatedScope[T any] struct{}
+
+var isolatedRegistries sync.Map // map[reflect.Type]*Registry
+
+// Registry returns the registry of T, cloning the default registry on first use.
+func (IsolatedScope[T]) Registry() *Registry {
+	key := reflect.TypeFor[T]()
+	if r, ok := isolatedRegistries.Load(key); ok {
+		return r.(*Registry)
+	}
+	r, _ := isolatedRegistries.LoadOrStore(key, defaultRegistry.Clone())
+	return r.(*Registry)
+}
+
+// ResetRegistries clears all types registered in the default registry and unfreezes it. Useful for tests.
+func ResetRegistries() {
+	defaultRegistry.Reset()
+}
+
+// Freeze makes the default registry read-only, see Registry.Freeze.
+func Freeze() {
+	defaultRegistry.Freeze()
+}
+
+// RegisterT registers a type T for interface I and discriminator X in the default registry.
+// T must not be a pointer and must implement I.
+func RegisterT[T any, I any, X comparable](x X) error {
+	return RegisterTIn[T, I](defaultRegistry, x)
+}
+
+// RegisterTIn registers a type T for interface I and discriminator X in registry r.
+// T must not be a pointer and must implement I.
+func RegisterTIn[T any, I any, X comparable](r *Registry, x X) error {
+	if reflect.TypeFor[T]().Kind() == reflect.Pointer {
+		return &FactoryError{Err: ErrPointerType, Interface: reflect.TypeFor[I](), Type: reflect.TypeFor[T]()}
+	}
+
+	if _, ok := any(new(T)).(I); !ok {
+		return &FactoryError{Err: ErrNotImplemented, Interface: reflect.TypeFor[I](), Type: reflect.TypeFor[T]()}
+	}
+	return RegisterIn[I, X](r, x, func() I {
+		return any(new(T)).(I)
+	})
+}
+
+// Register registers a factory function for interface I and discriminator X in the default registry.
+// The factory must return a pointer type.
+// The first discriminator registered for a concrete type is used when marshaling.
+func Register[I any, X comparable](x X, factory func() I) error {
+	return RegisterIn(defaultRegistry, x, factory)
+}
+
+// RegisterIn registers a factory function for interface I and discriminator X in registry r.
+// The factory must return a pointer type.
+// The first discriminator registered for a concrete type is used when marshaling.
+func RegisterIn[I any, X comparable](r *Registry, x X, factory func() I) error {
+	return registerIn[I, noSelector](r, x, factory, nil)
+}
+
+// registerIn registers a factory function for interface I, selector S and discriminator X in registry r.
+// selector is reported in errors and is nil for noSelector.
+func registerIn[I any, S any, X comparable](r *Registry, x X, factory func() I, selector reflect.Type) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.Frozen() {
+		return ErrFrozen
+	}
+
+	v := factory()
+	if err := checkFactory[I](v); err != nil {
+		return err
+	}
+
+	t := loadTableLocked[I, S, X](r)
+	if _, ok := t.factory(x); ok {
+		return &DuplicateError{Interface: reflect.TypeFor[I](), Selector: selector, Discriminator: reflect.TypeFor[X](), Value: x}
+	}
+
+	t = t.clone()
+	typ := reflect.TypeOf(v)
+	t.factories[x] = factory
+	if _, ok := t.types[typ]; !ok {
+		t.types[typ] = x
+	}
+	t.list = append(t.list, Registration[I, X]{X: x, Type: typ, Factory: factory})
+	storeTable[I, S](r, t)
+	return nil
+}
+
+// RegisterDefault registers a fallback factory for interface I and discriminator X in the default registry.
+// It is used for discriminator values without a registered factory.
+// The factory must return a pointer type.
+func RegisterDefault[I any, X comparable](factory func(x X) I) error {
+	return RegisterDefaultIn(defaultRegistry, factory)
+}
+
+// RegisterDefaultIn registers a fallback factory for interface I and discriminator X in registry r.
+// It is used for discriminator values without a registered factory.
+// The factory must return a pointer type.
+func RegisterDefaultIn[I any, X comparable](r *Registry, factory func(x X) I) error {
+	return registerDefaultIn[I, noSelector](r, factory, nil)
+}
+
+// registerDefaultIn registers a fallback factory for interface I, selector S and discriminator X in registry r.
+// selector is reported in errors and is nil for noSelector.
+func registerDefaultIn[I any, S any, X comparable](r *Registry, factory func(x X) I, selector reflect.Type) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.Frozen() {
+		return ErrFrozen
+	}
+
+	if err := checkFactory[I](factory(*new(X))); err != nil {
+		return err
+	}
+
+	t := loadTableLocked[I, S, X](r)
+	if t != nil && t.fallback != nil {
+		return &DuplicateError{Interface: reflect.TypeFor[I](), Selector: selector, Discriminator: reflect.TypeFor[X](), Default: true}
+	}
+
+	t = t.clone()
+	t.fallback = factory
+	storeTable[I, S](r, t)
+	return nil
+}
+
+// ScopedDecider resolves a concrete type from the registry selected by S based on discriminator value.
+type ScopedDecider[I any, X comparable, S Scope] struct{}
+
+// RegistryDecider resolves a concrete type from the default registry based on discriminator value.
+type RegistryDecider[I any, X comparable] = ScopedDecider[I, X, DefaultScope]
+
+// Decide returns a new instance of I from the registry for discriminator x.
+// If no factory is registered for x, the fallback registered with RegisterDefault is used.
+func (ScopedDecider[I, X, S]) Decide(x X) (I, error) {
+	i, ok := loadTable[I, noSelector, X]((*new(S)).Registry()).decide(x)
+	if !ok {
+		return i, &NotRegisteredError{Interface: reflect.TypeFor[I](), Discriminator: reflect.TypeFor[X](), Value: x}
+	}
+	return i, nil
+}
+
+// Discriminate returns the discriminator registered for the concrete type of i.
+func (ScopedDecider[I, X, S]) Discriminate(i I) (X, bool) {
+	return DiscriminatorForIn[I, X]((*new(S)).Registry(), reflect.TypeOf(i))
+}
+
+// RegisterF registers a factory function for interface I, discriminator X and field selector F
+// in the default registry.
+func RegisterF[I any, F FSelector, X comparable](x X, factory func() I) error {
+	return RegisterFIn[I, F](defaultRegistry, x, factory)
+}
+
+// RegisterFIn registers a factory function for interface I, discriminator X and field selector F
+// in registry r.
+func RegisterFIn[I any, F FSelector, X comparable](r *Registry, x X, factory func() I) error {
+	return registerIn[I, F](r, x, factory, reflect.TypeFor[F]())
+}
+
+// RegisterDefaultF registers a fallback factory for interface I, discriminator X and field selector F
+// in the default registry. It is used for discriminator values without a registered factory.
+func RegisterDefaultF[I any, F FSelector, X comparable](factory func(x X) I) error {
+	return RegisterDefaultFIn[I, F](defaultRegistry, factory)
+}
+
+// RegisterDefaultFIn registers a fallback factory for interface I, discriminator X and field selector F
+// in registry r. It is used for discriminator values without a registered factory.
+func RegisterDefaultFIn[I any, F FSelector, X comparable](r *Registry, factory func(x X) I) error {
+	return registerDefaultIn[I, F](r, factory, reflect.TypeFor[F]())
+}
+
+// ScopedFDecider resolves a concrete type from the registry selected by S
+// based on a discriminator field.
+type ScopedFDecider[I any, F FSelector, X comparable, S Scope] struct{}
+
+// FDecider resolves a concrete type from the default registry based on a discriminator field.
+type FDecider[I any, F FSelector, X comparable] = ScopedFDecider[I, F, X, DefaultScope]
+
+// Decide returns a new instance of I from the registry for the value of the discriminator field.
+// If no factory is registered for the field value, the fallback registered with RegisterDefaultF is used.
+func (ScopedFDecider[I, F, X, S]) Decide(v FValue[F, X]) (I, error) {
+	if !v.Found {
+		var i I
+		return i, &MissingFieldError{Interface: reflect.TypeFor[I](), Selector: reflect.TypeFor[F](), Field: (*new(F)).FieldName()}
+	}
+
+	i, ok := loadTable[I, F, X]((*new(S)).Registry()).decide(v.X)
+	if !ok {
+		return i, &NotRegisteredError{Interface: reflect.TypeFor[I](), Selector: reflect.TypeFor[F](), Discriminator: reflect.TypeFor[X](), Value: v.X}
+	}
+	return i, nil
+}
+
+// Discriminate returns the discriminator registered for the concrete type of i
+// as the value of the field named by F.
+func (ScopedFDecider[I, F, X, S]) Discriminate(i I) (FValue[F, X], bool) {
+	x, ok := DiscriminatorForFIn[I, F, X]((*new(S)).Registry(), reflect.TypeOf(i))
+	if !ok {
+		return FValue[F, X]{}, false
+	}
+	return FValue[F, X]{X: x, Found: true}, true
+}
@@ -0,0 +1,114 @@
+package ijson_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/Nikkolix/ijson"
+)
+
+var (
+	libraryARegistry = ijson.NewRegistry()
+	libraryBRegistry = ijson.NewRegistry()
+)
+
+type LibraryAScope struct{}
+
+func (LibraryAScope) Registry() *ijson.Registry { return libraryARegistry }
+
+type LibraryBScope struct{}
+
+func (LibraryBScope) Registry() *ijson.Registry { return libraryBRegistry }
+
+func TestRegistry_ScopesDoNotInterfere(t *testing.T) {
+	t.Parallel()
+
+	libraryARegistry.Reset()
+	libraryBRegistry.Reset()
+
+	require.NoError(t, ijson.RegisterTIn[PersonStruct, UnmarshalTestInterface](libraryARegistry, "entity"))
+	require.NoError(t, ijson.RegisterTIn[AnimalStruct, UnmarshalTestInterface](libraryBRegistry, "entity"))
+
+	var a ijson.ScopedDecider[UnmarshalTestInterface, string, LibraryAScope]
+	result, err := a.Decide("entity")
+	require.NoError(t, err)
+	assert.IsType(t, &PersonStruct{}, result)
+
+	var b ijson.ScopedDecider[UnmarshalTestInterface, string, LibraryBScope]
+	result, err = b.Decide("entity")
+	require.NoError(t, err)
+	assert.IsType(t, &AnimalStruct{}, result)
+
+	x, ok := b.Discriminate(&AnimalStruct{})
+	assert.True(t, ok)
+	assert.Equal(t, "entity", x)
+
+	_, ok = b.Discriminate(&PersonStruct{})
+	assert.False(t, ok)
+
+	_, err = ijson.RegistryDecider[UnmarshalTestInterface, string]{}.Decide("entity")
+	require.Error(t, err)
+}
+
+type ScopedDisc struct {
+	Kind string `json:"kind" msgpack:"kind"`
+}
+
+var parallelRegistry = ijson.NewRegistry()
+
+type ParallelScope struct{}
+
+func (ParallelScope) Registry() *ijson.Registry { return parallelRegistry }
+
+func TestRegistry_SDecodable_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	parallelRegistry.Reset()
+	require.NoError(t, ijson.RegisterTIn[Untyped, UnmarshalTestInterface](parallelRegistry, ScopedDisc{Kind: "u"}))
+
+	in := ijson.SDecodable[UnmarshalTestInterface, ScopedDisc, ParallelScope]{I: &Untyped{Name: "Fido"}}
+
+	data, err := json.Marshal(in)
+	require.NoError(t, err)
+	assert.Equal(t, `{"kind":"u","name":"Fido"}`, string(data))
+
+	var out ijson.SDecodable[UnmarshalTestInterface, ScopedDisc, ParallelScope]
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, &Untyped{Name: "Fido"}, out.I)
+
+	packed, err := msgpack.Marshal(in)
+	require.NoError(t, err)
+
+	out = ijson.SDecodable[UnmarshalTestInterface, ScopedDisc, ParallelScope]{}
+	require.NoError(t, msgpack.Unmarshal(packed, &out))
+	assert.Equal(t, &Untyped{Name: "Fido"}, out.I)
+}
+
+var parallelFRegistry ijson.Registry
+
+type ParallelFScope struct{}
+
+func (ParallelFScope) Registry() *ijson.Registry { return &parallelFRegistry }
+
+func TestRegistry_SDecodableF_ZeroValueRegistry(t *testing.T) {
+	t.Parallel()
+
+	parallelFRegistry.Reset()
+	require.NoError(t, ijson.RegisterFIn[XFTestInterface, TestFSelector](&parallelFRegistry, "A", func() XFTestInterface { return &XA{} }))
+
+	var d ijson.SDecodableF[XFTestInterface, TestFSelector, string, ParallelFScope]
+	require.NoError(t, json.Unmarshal([]byte(`{"type":"A","value":"v"}`), &d))
+	assert.Equal(t, &XA{Type: "A", Value: "v"}, d.I)
+
+	var other ijson.DecodableF[XFTestInterface, TestFSelector, string]
+	err := json.Unmarshal([]byte(`{"type":"Z","value":"v"}`), &other)
+	require.Error(t, err)
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	assert.Same(t, ijson.DefaultRegistry(), ijson.DefaultScope{}.Registry())
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"reflect"
+	"slices"
+)
+
+// replaceIn registers factory for x, replacing a previous registration in place.
+func replaceIn[I any, S any, X comparable](r *Registry, x X, factory func() I) error {
+	_, _, err := swapIn[I, S](r, x, factory)
+	return err
+}
+
+// swapIn is replaceIn returning the factory previously registered for x, if there was one.
+// Reading and replacing the registration happen under one lock.
+func swapIn[I any, S any, X comparable](r *Registry, x X, factory func() I) (func() I, bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.Frozen() {
+		return nil, false, ErrFrozen
+	}
+
+	v := factory()
+	if err := checkFactory[I](v); err != nil {
+		return nil, false, err
+	}
+
+	t := loadTableLocked[I, S, X](r).clone()
+	registration := Registration[I, X]{X: x, Type: reflect.TypeOf(v), Factory: factory}
+	previous, ok := t.factory(x)
+
+	var previousType reflect.Type
+	if i := slices.IndexFunc(t.list, func(reg Registration[I, X]) bool { return reg.X == x }); i >= 0 {
+		previousType = t.list[i].Type
+		t.list = slices.Clone(t.list)
+		t.list[i] = registration
+	} else {
+		t.list = append(t.list, registration)
+	}
+
+	t.factories[x] = factory
+	t.reindex(previousType)
+	t.reindex(registration.Type)
+	storeTable[I, S](r, t)
+	return previous, ok, nil
+}
+
+// unregisterIn removes the registration for x and reports whether there was one.
+func unregisterIn[I any, S any, X comparable](r *Registry, x X) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.Frozen() {
+		return false, ErrFrozen
+	}
+
+	t := loadTableLocked[I, S, X](r)
+	i := slices.IndexFunc(t.registrations(), func(reg Registration[I, X]) bool { return reg.X == x })
+	if i < 0 {
+		return false, nil
+	}
+
+	t = t.clone()
+	previous := t.list[i].Type
+	t.list = slices.Delete(slices.Clone(t.list), i, i+1)
+	delete(t.factories, x)
+	t.reindex(previous)
+	storeTable[I, S](r, t)
+	return true, nil
+}
+
+// Unregister removes the factory registered for interface I and discriminator value x from the default registry.
+// It reports whether a factory was registered, and fails with ErrFrozen if the registry is frozen.
+func Unregister[I any, X comparable](x X) (bool, error) {
+	return UnregisterIn[I](defaultRegistry, x)
+}
+
+// UnregisterIn removes the factory registered for interface I and discriminator value x from registry r.
+// It reports whether a factory was registered, and fails with ErrFrozen if r is frozen.
+func UnregisterIn[I any, X comparable](r *Registry, x X) (bool, error) {
+	return unregisterIn[I, noSelector](r, x)
+}
+
+// Replace registers a factory function for interface I and discriminator X in the default registry.
+// Unlike Register, an existing registration for x is replaced and keeps its position.
+func Replace[I any, X comparable](x X, factory func() I) error {
+	return ReplaceIn(defaultRegistry, x, factory)
+}
+
+// ReplaceIn registers a factory function for interface I and discriminator X in registry r.
+// Unlike RegisterIn, an existing registration for x is replaced and keeps its position.
+func ReplaceIn[I any, X comparable](r *Registry, x X, factory func() I) error {
+	return replaceIn[I, noSelector](r, x, factory)
+}
+
+// UnregisterF removes the factory registered for interface I, field selector F and discriminator value x
+// from the default registry. It reports whether a factory was registered,
+// and fails with ErrFrozen if the registry is frozen.
+func UnregisterF[I any, F FSelector, X comparable](x X) (bool, error) {
+	return UnregisterFIn[I, F](defaultRegistry, x)
+}
+
+// UnregisterFIn removes the factory registered for interface I, field selector F and discriminator value x
+// from registry r. It reports whether a factory was registered, and fails with ErrFrozen if r is frozen.
+func UnregisterFIn[I any, F FSelector, X comparable](r *Registry, x X) (bool, error) {
+	return unregisterIn[I, F](r, x)
+}
+
+// ReplaceF registers a factory function for interface I, discriminator X and field selector F
+// in the default registry. Unlike RegisterF, an existing registration for x is replaced and keeps its position.
+func ReplaceF[I any, F FSelector, X comparable](x X, factory func() I) error {
+	return ReplaceFIn[I, F](defaultRegistry, x, factory)
+}
+
+// ReplaceFIn registers a factory function for interface I, discriminator X and field selector F
+// in registry r. Unlike RegisterFIn, an existing registration for x is replaced and keeps its position.
+func ReplaceFIn[I any, F FSelector, X comparable](r *Registry, x X, factory func() I) error {
+	return replaceIn[I, F](r, x, factory)
+}
@@ -0,0 +1,154 @@
+package ijson_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Nikkolix/ijson"
+)
+
+func TestUnregister(t *testing.T) {
+	registerShapes(t)
+	require.NoError(t, ijson.RegisterT[Circle, Shape]("round"))
+
+	ok, err := ijson.Unregister[Shape]("circle")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = ijson.Unregister[Shape]("circle")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok = ijson.Lookup[Shape]("circle")
+	assert.False(t, ok)
+	x, ok := ijson.DiscriminatorFor[Shape, string](reflect.TypeFor[*Circle]())
+	assert.True(t, ok)
+	assert.Equal(t, "round", x, "the next registration of the type is used for marshaling")
+
+	ok, err = ijson.Unregister[Shape]("round")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, ok = ijson.DiscriminatorFor[Shape, string](reflect.TypeFor[*Circle]())
+	assert.False(t, ok)
+
+	registrations := ijson.Registrations[Shape, string]()
+	require.Len(t, registrations, 1)
+	assert.Equal(t, "square", registrations[0].X)
+
+	require.NoError(t, ijson.RegisterT[Circle, Shape]("circle"))
+	_, err = ijson.RegistryDecider[Shape, string]{}.Decide("circle")
+	assert.NoError(t, err)
+}
+
+func TestReplace(t *testing.T) {
+	registerShapes(t)
+
+	require.NoError(t, ijson.Replace[Shape]("circle", func() Shape { return &Square{} }))
+	factory, ok := ijson.Lookup[Shape]("circle")
+	require.True(t, ok)
+	assert.Equal(t, &Square{}, factory())
+
+	registrations := ijson.Registrations[Shape, string]()
+	require.Len(t, registrations, 2)
+	assert.Equal(t, "circle", registrations[0].X)
+	assert.Equal(t, reflect.TypeFor[*Square](), registrations[0].Type)
+
+	x, _ := ijson.DiscriminatorFor[Shape, string](reflect.TypeFor[*Square]())
+	assert.Equal(t, "circle", x)
+	_, ok = ijson.DiscriminatorFor[Shape, string](reflect.TypeFor[*Circle]())
+	assert.False(t, ok)
+
+	require.NoError(t, ijson.Replace[Shape]("triangle", func() Shape { return &Circle{} }))
+	x, _ = ijson.DiscriminatorFor[Shape, string](reflect.TypeFor[*Circle]())
+	assert.Equal(t, "triangle", x)
+	assert.Len(t, ijson.Registrations[Shape, string](), 3)
+
+	err := ijson.Replace[any]("x", func() any { return Circle{} })
+	assert.ErrorIs(t, err, ijson.ErrNonPointerFactory)
+}
+
+func TestReplaceF_UnregisterF(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterF[XFTestInterface, TestFSelector]("A", func() XFTestInterface { return &XA{} }))
+
+	require.NoError(t, ijson.ReplaceF[XFTestInterface, TestFSelector]("A", func() XFTestInterface { return &XB{} }))
+	factory, ok := ijson.LookupF[XFTestInterface, TestFSelector]("A")
+	require.True(t, ok)
+	assert.Equal(t, &XB{}, factory())
+
+	ok, err := ijson.UnregisterF[XFTestInterface, TestFSelector]("A")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = ijson.UnregisterF[XFTestInterface, TestFSelector]("A")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Empty(t, ijson.RegistrationsF[XFTestInterface, TestFSelector, string]())
+}
+
+func TestRegisterTest(t *testing.T) {
+	registerShapes(t)
+
+	t.Run("replace", func(t *testing.T) {
+		ijson.RegisterTest[Shape](t, "circle", func() Shape { return &Square{} })
+		ijson.RegisterTest[Shape](t, "hexagon", func() Shape { return &Circle{} })
+
+		factory, _ := ijson.Lookup[Shape]("circle")
+		assert.Equal(t, &Square{}, factory())
+		_, ok := ijson.Lookup[Shape]("hexagon")
+		assert.True(t, ok)
+	})
+
+	factory, ok := ijson.Lookup[Shape]("circle")
+	require.True(t, ok)
+	assert.Equal(t, &Circle{}, factory())
+	_, ok = ijson.Lookup[Shape]("hexagon")
+	assert.False(t, ok)
+	x, _ := ijson.DiscriminatorFor[Shape, string](reflect.TypeFor[*Circle]())
+	assert.Equal(t, "circle", x)
+
+	registrations := ijson.Registrations[Shape, string]()
+	require.Len(t, registrations, 2)
+	assert.Equal(t, "circle", registrations[0].X)
+}
+
+func TestRegisterFTest(t *testing.T) {
+	t.Parallel()
+	r := ijson.NewRegistry()
+
+	t.Run("register", func(t *testing.T) {
+		ijson.RegisterFTestIn[XFTestInterface, TestFSelector](t, r, "A", func() XFTestInterface { return &XA{} })
+		_, ok := ijson.LookupFIn[XFTestInterface, TestFSelector](r, "A")
+		assert.True(t, ok)
+	})
+
+	_, ok := ijson.LookupFIn[XFTestInterface, TestFSelector](r, "A")
+	assert.False(t, ok)
+}
+
+type fakeTB struct {
+	cleanups []func()
+	fatal    string
+}
+
+func (*fakeTB) Helper()                             {}
+func (f *fakeTB) Cleanup(fn func())                 { f.cleanups = append(f.cleanups, fn) }
+func (f *fakeTB) Fatalf(format string, args ...any) { f.fatal = fmt.Sprintf(format, args...) }
+
+func TestRegisterTest_Fatal(t *testing.T) {
+	ijson.ResetRegistries()
+
+	var tb fakeTB
+	ijson.RegisterTest[any](&tb, "x", func() any { return Circle{} })
+	assert.Equal(t, "ijson: register x: factory must return a pointer type, got ijson_test.Circle", tb.fatal)
+	assert.Empty(t, tb.cleanups)
+
+	tb = fakeTB{}
+	ijson.RegisterFTest[XFTestInterface, TestFSelector](&tb, "A", func() XFTestInterface { return &XA{} })
+	assert.Empty(t, tb.fatal)
+	require.Len(t, tb.cleanups, 1)
+	tb.cleanups[0]()
+	assert.Empty(t, ijson.RegistrationsF[XFTestInterface, TestFSelector, string]())
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var (
+	_ json.Marshaler   = DecodableSlice[any, any, RegistryDecider[any, any], Strict]{}
+	_ json.Unmarshaler = &DecodableSlice[any, any, RegistryDecider[any, any], Strict]{}
+
+	_ msgpack.Marshaler     = DecodableSlice[any, any, RegistryDecider[any, any], Strict]{}
+	_ msgpack.Unmarshaler   = &DecodableSlice[any, any, RegistryDecider[any, any], Strict]{}
+	_ msgpack.CustomEncoder = DecodableSlice[any, any, RegistryDecider[any, any], Strict]{}
+	_ msgpack.CustomDecoder = &DecodableSlice[any, any, RegistryDecider[any, any], Strict]{}
+)
+
+// ElementPolicy is an interface for types that choose how elements that fail to decode are handled.
+type ElementPolicy interface {
+	// SkipInvalid reports whether an element that fails to decode is dropped
+	// instead of failing the whole value.
+	SkipInvalid() bool
+	// CollectErrors reports whether the errors of dropped elements are returned as ElementsError
+	// after all other elements are decoded.
+	CollectErrors() bool
+	~struct{}
+}
+
+// Strict fails the whole value with an ElementError on the first element that fails to decode.
+type Strict struct{}
+
+// SkipInvalid returns false.
+func (Strict) SkipInvalid() bool { return false }
+
+// CollectErrors returns false.
+func (Strict) CollectErrors() bool { return false }
+
+// Skip silently drops elements that fail to decode.
+type Skip struct{}
+
+// SkipInvalid returns true.
+func (Skip) SkipInvalid() bool { return true }
+
+// CollectErrors returns false.
+func (Skip) CollectErrors() bool { return false }
+
+// Collect drops elements that fail to decode and returns their errors as ElementsError
+// together with the remaining elements.
+type Collect struct{}
+
+// SkipInvalid returns true.
+func (Collect) SkipInvalid() bool { return true }
+
+// CollectErrors returns true.
+func (Collect) CollectErrors() bool { return true }
+
+// ElementError describes an element that failed to decode.
+type ElementError struct {
+	Key any   // The index of the element in the array or its key in the map
+	Err error // The reason the element failed to decode
+}
+
+func (e *ElementError) Error() string {
+	return fmt.Sprintf("element %v: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the reason the element failed to decode.
+func (e *ElementError) Unwrap() error {
+	return e.Err
+}
+
+// ElementsError is returned with the Collect policy if elements were dropped.
+type ElementsError struct {
+	Interface reflect.Type   // The interface type I
+	Elements  []ElementError // The dropped elements, in input order for arrays
+}
+
+// Error lists every dropped element with the reason it failed to decode.
+func (e *ElementsError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d elements of I type %s failed to decode", len(e.Elements), e.Interface)
+	for _, element := range e.Elements {
+		sb.WriteString("; ")
+		sb.WriteString(element.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap returns the errors of all dropped elements.
+func (e *ElementsError) Unwrap() []error {
+	errs := make([]error, len(e.Elements))
+	for i := range e.Elements {
+		errs[i] = &e.Elements[i]
+	}
+	return errs
+}
+
+// elementErrors applies the policy P to the errors of elements.
+type elementErrors[I any, P ElementPolicy] struct {
+	collected []ElementError
+}
+
+// handle returns the error to fail the whole value with if key failed to decode with err, or nil to drop it.
+func (e *elementErrors[I, P]) handle(key any, err error) error {
+	var policy P
+	if !policy.SkipInvalid() {
+		return &ElementError{Key: key, Err: err}
+	}
+	if policy.CollectErrors() {
+		e.collected = append(e.collected, ElementError{Key: key, Err: err})
+	}
+	return nil
+}
+
+// err returns the collected errors or nil if there are none.
+func (e *elementErrors[I, P]) err() error {
+	if len(e.collected) == 0 {
+		return nil
+	}
+	return &ElementsError{Interface: reflect.TypeFor[I](), Elements: e.collected}
+}
+
+// DecodableSlice is a generic slice for polymorphic (de)serialization of arrays.
+// Every element is decoded like a Decodable[I, X, D], and null elements are nil.
+// I is the interface type, X is the discriminator type, D is the decider
+// and P is the policy for elements that fail to decode.
+type DecodableSlice[I any, X any, D Decider[I, X], P ElementPolicy] []I
+
+// RDecodableSlice is a type alias for DecodableSlice using RegistryDecider.
+type RDecodableSlice[I any, X comparable, P ElementPolicy] = DecodableSlice[I, X, RegistryDecider[I, X], P]
+
+// MarshalJSON marshals the elements into an array using JSON.
+func (s DecodableSlice[I, X, D, P]) MarshalJSON() ([]byte, error) {
+	if s == nil {
+		return []byte("null"), nil
+	}
+
+	elements := make([]Decodable[I, X, D], len(s))
+	for i, v := range s {
+		elements[i].I = v
+	}
+	return json.Marshal(elements)
+}
+
+// UnmarshalJSON does unmarshal the array in data into the elements using JSON.
+func (s *DecodableSlice[I, X, D, P]) UnmarshalJSON(data []byte) error {
+	var raws []json.RawMessage
+	if err := json.Unmarshal(data, &raws); err != nil {
+		return containerError[I, X](codecJSON, err)
+	}
+	if raws == nil {
+		*s = nil
+		return nil
+	}
+
+	var errs elementErrors[I, P]
+	elements := make(DecodableSlice[I, X, D, P], 0, len(raws))
+	for i, raw := range raws {
+		var d Decodable[I, X, D]
+		if !isJSONNull(raw) {
+			if err := d.UnmarshalJSON(raw); err != nil {
+				if err := errs.handle(i, err); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		elements = append(elements, d.I)
+	}
+	*s = elements
+	return errs.err()
+}
+
+// MarshalMsgpack marshals the elements into an array using msgpack.
+func (s DecodableSlice[I, X, D, P]) MarshalMsgpack() ([]byte, error) {
+	if s == nil {
+		return msgpack.Marshal(nil)
+	}
+
+	elements := make([]Decodable[I, X, D], len(s))
+	for i, v := range s {
+		elements[i].I = v
+	}
+	return msgpack.Marshal(elements)
+}
+
+// EncodeMsgpack encodes the elements into an array to the msgpack encoder.
+func (s DecodableSlice[I, X, D, P]) EncodeMsgpack(enc *msgpack.Encoder) error {
+	if s == nil {
+		return enc.EncodeNil()
+	}
+
+	elements := make([]Decodable[I, X, D], len(s))
+	for i, v := range s {
+		elements[i].I = v
+	}
+	return enc.Encode(elements)
+}
+
+// DecodeMsgpack decodes the next array of the msgpack decoder into the elements.
+func (s *DecodableSlice[I, X, D, P]) DecodeMsgpack(dec *msgpack.Decoder) error {
+	data, err := dec.DecodeRaw()
+	if err != nil {
+		return containerError[I, X](codecMsgpack, err)
+	}
+	return s.UnmarshalMsgpack(data)
+}
+
+// UnmarshalMsgpack does unmarshal the array in data into the elements using msgpack.
+func (s *DecodableSlice[I, X, D, P]) UnmarshalMsgpack(data []byte) error {
+	raws, err := splitMsgpackArray(data)
+	if err != nil {
+		return containerError[I, X](codecMsgpack, err)
+	}
+	if raws == nil {
+		*s = nil
+		return nil
+	}
+
+	var errs elementErrors[I, P]
+	elements := make(DecodableSlice[I, X, D, P], 0, len(raws))
+	for i, raw := range raws {
+		var d Decodable[I, X, D]
+		if !isMsgpackNil(raw) {
+			if err := d.UnmarshalMsgpack(raw); err != nil {
+				if err := errs.handle(i, err); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		elements = append(elements, d.I)
+	}
+	*s = elements
+	return errs.err()
+}
@@ -0,0 +1,161 @@
+package ijson_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/Nikkolix/ijson"
+)
+
+type Pet interface {
+	Sound() string
+}
+
+type PetKind struct {
+	Type string `json:"type" msgpack:"type"`
+}
+
+type Dog struct {
+	Name string `json:"name" msgpack:"name"`
+}
+
+func (*Dog) Sound() string { return "woof" }
+
+type Cat struct {
+	Lives int `json:"lives" msgpack:"lives"`
+}
+
+func (*Cat) Sound() string { return "meow" }
+
+func registerPets(t *testing.T) {
+	t.Helper()
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterT[Dog, Pet](PetKind{Type: "dog"}))
+	require.NoError(t, ijson.RegisterT[Cat, Pet](PetKind{Type: "cat"}))
+}
+
+func TestDecodableSlice_JSON_RoundTrip(t *testing.T) {
+	registerPets(t)
+
+	var pets ijson.RDecodableSlice[Pet, PetKind, ijson.Strict]
+	require.NoError(t, json.Unmarshal([]byte(`[{"type":"dog","name":"rex"},null,{"lives":9,"type":"cat"}]`), &pets))
+	assert.Equal(t, []Pet{&Dog{Name: "rex"}, nil, &Cat{Lives: 9}}, []Pet(pets))
+
+	data, err := json.Marshal(pets)
+	require.NoError(t, err)
+	assert.Equal(t, `[{"type":"dog","name":"rex"},null,{"type":"cat","lives":9}]`, string(data))
+
+	type Owner struct {
+		Pets ijson.RDecodableSlice[Pet, PetKind, ijson.Strict] `json:"pets"`
+	}
+	var owner Owner
+	require.NoError(t, json.Unmarshal([]byte(`{"pets":[]}`), &owner))
+	assert.NotNil(t, owner.Pets)
+	assert.Empty(t, owner.Pets)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"pets":null}`), &owner))
+	assert.Nil(t, owner.Pets)
+	data, err = json.Marshal(owner)
+	require.NoError(t, err)
+	assert.Equal(t, `{"pets":null}`, string(data))
+}
+
+func TestDecodableSlice_JSON_Policies(t *testing.T) {
+	registerPets(t)
+	data := []byte(`[{"type":"dog"},{"type":"fish"},{"type":"cat","lives":"nine"},{"type":"cat"}]`)
+
+	var strict ijson.RDecodableSlice[Pet, PetKind, ijson.Strict]
+	err := json.Unmarshal(data, &strict)
+	var elementErr *ijson.ElementError
+	require.ErrorAs(t, err, &elementErr)
+	assert.Equal(t, 1, elementErr.Key)
+	assert.ErrorIs(t, err, ijson.ErrNotRegistered)
+
+	var skip ijson.RDecodableSlice[Pet, PetKind, ijson.Skip]
+	require.NoError(t, json.Unmarshal(data, &skip))
+	assert.Equal(t, []Pet{&Dog{}, &Cat{}}, []Pet(skip))
+
+	var collect ijson.RDecodableSlice[Pet, PetKind, ijson.Collect]
+	err = json.Unmarshal(data, &collect)
+	assert.Equal(t, []Pet{&Dog{}, &Cat{}}, []Pet(collect))
+
+	var elementsErr *ijson.ElementsError
+	require.ErrorAs(t, err, &elementsErr)
+	require.Len(t, elementsErr.Elements, 2)
+	assert.Equal(t, 1, elementsErr.Elements[0].Key)
+	assert.Equal(t, 2, elementsErr.Elements[1].Key)
+	assert.ErrorIs(t, err, ijson.ErrNotRegistered)
+	assert.ErrorIs(t, err, ijson.ErrPayloadDecode)
+	assert.Contains(t, err.Error(), "2 elements of I type ijson_test.Pet failed to decode; element 1: json: decide ijson_test.Pet: no factory found")
+
+	var invalid ijson.RDecodableSlice[Pet, PetKind, ijson.Skip]
+	err = invalid.UnmarshalJSON([]byte(`{"type":"dog"}`))
+	var decodeErr *ijson.DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, ijson.PhaseContainer, decodeErr.Phase)
+	assert.Equal(t, "json", decodeErr.Codec)
+	assert.Contains(t, err.Error(), "json: decode elements of ijson_test.Pet: ")
+}
+
+func TestDecodableSlice_Msgpack_RoundTrip(t *testing.T) {
+	registerPets(t)
+
+	type Owner struct {
+		Pets ijson.RDecodableSlice[Pet, PetKind, ijson.Strict] `msgpack:"pets"`
+	}
+	in := Owner{Pets: ijson.RDecodableSlice[Pet, PetKind, ijson.Strict]{&Dog{Name: "rex"}, nil, &Cat{Lives: 9}}}
+	data, err := msgpack.Marshal(in)
+	require.NoError(t, err)
+
+	var raw map[string][]map[string]any
+	require.NoError(t, msgpack.Unmarshal(data, &raw))
+	assert.Equal(t, "dog", raw["pets"][0]["type"])
+	assert.Nil(t, raw["pets"][1])
+	assert.Equal(t, "cat", raw["pets"][2]["type"])
+
+	var out Owner
+	require.NoError(t, msgpack.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+
+	data, err = msgpack.Marshal(Owner{})
+	require.NoError(t, err)
+	require.NoError(t, msgpack.Unmarshal(data, &out))
+	assert.Nil(t, out.Pets)
+}
+
+func TestDecodableSlice_Msgpack_Policies(t *testing.T) {
+	registerPets(t)
+	data, err := msgpack.Marshal([]any{map[string]any{"type": "dog"}, map[string]any{"type": "fish"}, map[string]any{"type": "cat"}})
+	require.NoError(t, err)
+
+	var strict ijson.RDecodableSlice[Pet, PetKind, ijson.Strict]
+	err = strict.UnmarshalMsgpack(data)
+	var elementErr *ijson.ElementError
+	require.ErrorAs(t, err, &elementErr)
+	assert.Equal(t, "element 1: msgpack: decide ijson_test.Pet: no factory found in registry[I: ijson_test.Pet, X: ijson_test.PetKind] and X value {fish}", err.Error())
+
+	var skip ijson.RDecodableSlice[Pet, PetKind, ijson.Skip]
+	require.NoError(t, skip.UnmarshalMsgpack(data))
+	assert.Equal(t, []Pet{&Dog{}, &Cat{}}, []Pet(skip))
+
+	var collect ijson.RDecodableSlice[Pet, PetKind, ijson.Collect]
+	err = collect.UnmarshalMsgpack(data)
+	assert.ErrorIs(t, err, ijson.ErrNotRegistered)
+	assert.Equal(t, []Pet{&Dog{}, &Cat{}}, []Pet(collect))
+
+	notArray, err := msgpack.Marshal("dog")
+	require.NoError(t, err)
+	assert.Error(t, skip.UnmarshalMsgpack(notArray))
+}
+
+func TestDecodableSlice_Msgpack_HugeLength(t *testing.T) {
+	registerPets(t)
+
+	// array32 header claiming 2^32-1 elements without any element following
+	var s ijson.RDecodableSlice[Pet, PetKind, ijson.Strict]
+	assert.Error(t, s.UnmarshalMsgpack([]byte{0xdd, 0xff, 0xff, 0xff, 0xff}))
+}
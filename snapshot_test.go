@@ -0,0 +1,94 @@
+package ijson_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Nikkolix/ijson"
+)
+
+func TestRegistry_SnapshotRestore(t *testing.T) {
+	t.Parallel()
+	r := ijson.NewRegistry()
+	require.NoError(t, ijson.RegisterTIn[Circle, Shape](r, "circle"))
+
+	snapshot := r.Snapshot()
+
+	require.NoError(t, ijson.RegisterTIn[Square, Shape](r, "square"))
+	require.NoError(t, ijson.RegisterUntaggedIn[Shape](r, func() Shape { return &Point{} }))
+	ok, err := ijson.UnregisterIn[Shape](r, "circle")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Len(t, ijson.RegistrationsIn[Shape, string](r), 1)
+
+	r.Restore(snapshot)
+	registrations := ijson.RegistrationsIn[Shape, string](r)
+	require.Len(t, registrations, 1)
+	assert.Equal(t, "circle", registrations[0].X)
+	_, ok = ijson.LookupIn[Shape](r, "square")
+	assert.False(t, ok)
+
+	require.NoError(t, ijson.RegisterTIn[Square, Shape](r, "square"))
+	r.Restore(snapshot)
+	assert.Len(t, ijson.RegistrationsIn[Shape, string](r), 1, "a snapshot can be restored repeatedly")
+
+	r.Restore(ijson.Snapshot{})
+	assert.Empty(t, ijson.RegistrationsIn[Shape, string](r))
+	require.NoError(t, ijson.RegisterTIn[Square, Shape](r, "square"))
+}
+
+func TestRegistry_SnapshotSharesNoState(t *testing.T) {
+	t.Parallel()
+	r := ijson.NewRegistry()
+	require.NoError(t, ijson.RegisterTIn[Circle, Shape](r, "a"))
+	require.NoError(t, ijson.RegisterTIn[Circle, Shape](r, "b"))
+	snapshot := r.Snapshot()
+
+	// appending to the restored registration list must not leak into the other registry
+	clone := r.Clone()
+	require.NoError(t, ijson.RegisterTIn[Square, Shape](r, "c"))
+	require.NoError(t, ijson.RegisterTIn[Circle, Shape](clone, "d"))
+
+	assert.Equal(t, []string{"a", "b", "c"}, discriminators(ijson.RegistrationsIn[Shape, string](r)))
+	assert.Equal(t, []string{"a", "b", "d"}, discriminators(ijson.RegistrationsIn[Shape, string](clone)))
+
+	r.Restore(snapshot)
+	assert.Equal(t, []string{"a", "b"}, discriminators(ijson.RegistrationsIn[Shape, string](r)))
+}
+
+func discriminators(registrations []ijson.Registration[Shape, string]) []string {
+	xs := make([]string, len(registrations))
+	for i, r := range registrations {
+		xs[i] = r.X
+	}
+	return xs
+}
+
+func TestIsolatedScope(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterT[Circle, Shape]("circle"))
+
+	type isolatedA struct{}
+	type isolatedB struct{}
+	a := ijson.IsolatedScope[isolatedA]{}.Registry()
+	assert.Same(t, a, ijson.IsolatedScope[isolatedA]{}.Registry())
+	assert.NotSame(t, a, ijson.IsolatedScope[isolatedB]{}.Registry())
+	require.NoError(t, ijson.RegisterTIn[Square, Shape](a, "square"))
+
+	var inA ijson.ScopedDecider[Shape, string, ijson.IsolatedScope[isolatedA]]
+	shape, err := inA.Decide("circle")
+	require.NoError(t, err)
+	assert.IsType(t, &Circle{}, shape)
+	shape, err = inA.Decide("square")
+	require.NoError(t, err)
+	assert.IsType(t, &Square{}, shape)
+
+	var inB ijson.ScopedDecider[Shape, string, ijson.IsolatedScope[isolatedB]]
+	_, err = inB.Decide("square")
+	assert.ErrorIs(t, err, ijson.ErrNotRegistered)
+	var inDefault ijson.RegistryDecider[Shape, string]
+	_, err = inDefault.Decide("square")
+	assert.ErrorIs(t, err, ijson.ErrNotRegistered)
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// tableKey identifies the table for interface I, selector S and discriminator X in a registry.
+// S is noSelector for plain discriminators, a FSelector or a MSelector.
+type tableKey[I any, S any, X comparable] struct{}
+
+// noSelector is the selector of tables for discriminators that are not read from a named field.
+type noSelector struct{}
+
+// table holds the registrations for one interface, selector and discriminator type.
+// A table is never modified after it is stored in a registry, so it can be read without holding the lock.
+// All methods accept a nil table, which has no registrations.
+type table[I any, X comparable] struct {
+	factories map[X]func() I
+	types     map[reflect.Type]X // the discriminator used to marshal each concrete type
+	fallback  func(X) I
+	list      []Registration[I, X]
+}
+
+// loadTable returns the table for interface I, selector S and discriminator X in registry r or nil.
+func loadTable[I any, S any, X comparable](r *Registry) *table[I, X] {
+	// tableKey[I, S, X] is only ever stored with a *table[I, X], so the assertion cannot fail.
+	t, _ := r.get(reflect.TypeFor[tableKey[I, S, X]]()).(*table[I, X])
+	return t
+}
+
+// loadTableLocked is loadTable for callers holding the write lock.
+func loadTableLocked[I any, S any, X comparable](r *Registry) *table[I, X] {
+	t, _ := r.tables[reflect.TypeFor[tableKey[I, S, X]]()].(*table[I, X])
+	return t
+}
+
+// storeTable stores the table for interface I, selector S and discriminator X in registry r.
+// The caller must hold the write lock.
+func storeTable[I any, S any, X comparable](r *Registry, t *table[I, X]) {
+	r.set(reflect.TypeFor[tableKey[I, S, X]](), t)
+}
+
+// clone returns a copy of t that can be modified.
+func (t *table[I, X]) clone() *table[I, X] {
+	c := &table[I, X]{factories: map[X]func() I{}, types: map[reflect.Type]X{}}
+	if t == nil {
+		return c
+	}
+
+	maps.Copy(c.factories, t.factories)
+	maps.Copy(c.types, t.types)
+	c.fallback = t.fallback
+	c.list = slices.Clip(t.list)
+	return c
+}
+
+// factory returns the factory registered for x.
+func (t *table[I, X]) factory(x X) (func() I, bool) {
+	if t == nil {
+		return nil, false
+	}
+	factory, ok := t.factories[x]
+	return factory, ok
+}
+
+// discriminator returns the discriminator used to marshal the concrete type typ.
+func (t *table[I, X]) discriminator(typ reflect.Type) (X, bool) {
+	if t == nil {
+		var x X
+		return x, false
+	}
+	x, ok := t.types[typ]
+	return x, ok
+}
+
+// registrations returns the registrations in registration order. The result must not be modified.
+func (t *table[I, X]) registrations() []Registration[I, X] {
+	if t == nil {
+		return nil
+	}
+	return t.list
+}
+
+// decide returns a new instance of I for x from its factory or the fallback.
+func (t *table[I, X]) decide(x X) (I, bool) {
+	if factory, ok := t.factory(x); ok {
+		return factory(), true
+	}
+	if t != nil && t.fallback != nil {
+		return t.fallback(x), true
+	}
+	var i I
+	return i, false
+}
+
+// reindex sets the discriminator of the concrete type typ to the first value registered for typ.
+func (t *table[I, X]) reindex(typ reflect.Type) {
+	if typ == nil {
+		return
+	}
+
+	for _, reg := range t.list {
+		if reg.Type == typ {
+			t.types[typ] = reg.X
+			return
+		}
+	}
+	delete(t.types, typ)
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+// TB is the subset of testing.TB used by the test helpers.
+type TB interface {
+	Helper()
+	Cleanup(func())
+	Fatalf(format string, args ...any)
+}
+
+// RegisterTest registers a factory function for interface I and discriminator X in the default registry
+// for the lifetime of the test tb. A previous registration for x is replaced and restored by tb.Cleanup.
+func RegisterTest[I any, X comparable](tb TB, x X, factory func() I) {
+	tb.Helper()
+	RegisterTestIn(tb, defaultRegistry, x, factory)
+}
+
+// RegisterTestIn registers a factory function for interface I and discriminator X in registry r
+// for the lifetime of the test tb. A previous registration for x is replaced and restored by tb.Cleanup.
+func RegisterTestIn[I any, X comparable](tb TB, r *Registry, x X, factory func() I) {
+	tb.Helper()
+	registerTest[I, noSelector](tb, r, x, factory)
+}
+
+// RegisterFTest registers a factory function for interface I, discriminator X and field selector F
+// in the default registry for the lifetime of the test tb.
+// A previous registration for x is replaced and restored by tb.Cleanup.
+func RegisterFTest[I any, F FSelector, X comparable](tb TB, x X, factory func() I) {
+	tb.Helper()
+	RegisterFTestIn[I, F](tb, defaultRegistry, x, factory)
+}
+
+// RegisterFTestIn registers a factory function for interface I, discriminator X and field selector F
+// in registry r for the lifetime of the test tb.
+// A previous registration for x is replaced and restored by tb.Cleanup.
+func RegisterFTestIn[I any, F FSelector, X comparable](tb TB, r *Registry, x X, factory func() I) {
+	tb.Helper()
+	registerTest[I, F](tb, r, x, factory)
+}
+
+func registerTest[I any, S any, X comparable](tb TB, r *Registry, x X, factory func() I) {
+	tb.Helper()
+
+	previous, ok, err := swapIn[I, S](r, x, factory)
+	if err != nil {
+		tb.Fatalf("ijson: register %v: %v", x, err)
+		return
+	}
+
+	tb.Cleanup(func() {
+		if ok {
+			_ = replaceIn[I, S](r, x, previous)
+		} else {
+			_, _ = unregisterIn[I, S](r, x)
+		}
+	})
+}
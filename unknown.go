@@ -0,0 +1,181 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	_ json.Marshaler      = Unknown[any]{}
+	_ json.Unmarshaler    = &Unknown[any]{}
+	_ msgpack.Marshaler   = Unknown[any]{}
+	_ msgpack.Unmarshaler = &Unknown[any]{}
+	_ yaml.Marshaler      = Unknown[any]{}
+	_ yaml.Unmarshaler    = &Unknown[any]{}
+	_ cbor.Marshaler      = Unknown[any]{}
+	_ cbor.Unmarshaler    = &Unknown[any]{}
+	_ bson.Marshaler      = Unknown[any]{}
+	_ bson.Unmarshaler    = &Unknown[any]{}
+	_ xml.Marshaler       = Unknown[any]{}
+	_ xml.Unmarshaler     = &Unknown[any]{}
+	_ Tagged[any]         = Unknown[any]{}
+)
+
+// Tagged is an interface for values that carry their own discriminator.
+// Adjacently and externally tagged marshaling uses it for values the decider has no discriminator for.
+type Tagged[X any] interface {
+	// Tag returns the discriminator of the value.
+	Tag() X
+}
+
+// Unknown keeps a value whose discriminator has no registered factory,
+// so it can be re-marshaled unchanged with the codec it was decoded with.
+// Embed it into a type implementing I and return that type from a factory registered with RegisterDefault:
+//
+//	type UnknownAnimal struct{ ijson.Unknown[string] }
+//
+//	_ = ijson.RegisterDefault(func(x string) Animal { return &UnknownAnimal{ijson.Unknown[string]{X: x}} })
+type Unknown[X any] struct {
+	X       X                  // The discriminator the value was decoded with
+	JSON    json.RawMessage    // The JSON payload, if decoded from JSON
+	Msgpack msgpack.RawMessage // The msgpack payload, if decoded from msgpack
+	YAML    *yaml.Node         // The YAML node, if decoded from YAML
+	CBOR    cbor.RawMessage    // The CBOR payload, if decoded from CBOR
+	BSON    bson.Raw           // The BSON document, if decoded from BSON
+	XML     []byte             // The XML element, if decoded from XML
+}
+
+// Tag returns the discriminator the value was decoded with.
+func (u Unknown[X]) Tag() X {
+	return u.X
+}
+
+// MarshalJSON returns the JSON payload unchanged.
+func (u Unknown[X]) MarshalJSON() ([]byte, error) {
+	if u.JSON == nil {
+		return nil, fmt.Errorf("unknown value for discriminator %v has no JSON payload", u.X)
+	}
+	return u.JSON, nil
+}
+
+// UnmarshalJSON keeps a copy of data as JSON payload.
+func (u *Unknown[X]) UnmarshalJSON(data []byte) error {
+	u.JSON = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// MarshalMsgpack returns the msgpack payload unchanged.
+func (u Unknown[X]) MarshalMsgpack() ([]byte, error) {
+	if u.Msgpack == nil {
+		return nil, fmt.Errorf("unknown value for discriminator %v has no msgpack payload", u.X)
+	}
+	return u.Msgpack, nil
+}
+
+// UnmarshalMsgpack keeps a copy of data as msgpack payload.
+func (u *Unknown[X]) UnmarshalMsgpack(data []byte) error {
+	u.Msgpack = append(msgpack.RawMessage(nil), data...)
+	return nil
+}
+
+// MarshalYAML returns the YAML node unchanged.
+func (u Unknown[X]) MarshalYAML() (any, error) {
+	if u.YAML == nil {
+		return nil, fmt.Errorf("unknown value for discriminator %v has no YAML payload", u.X)
+	}
+	return u.YAML, nil
+}
+
+// UnmarshalYAML keeps node as YAML node.
+func (u *Unknown[X]) UnmarshalYAML(node *yaml.Node) error {
+	u.YAML = node
+	return nil
+}
+
+// MarshalCBOR returns the CBOR payload unchanged.
+func (u Unknown[X]) MarshalCBOR() ([]byte, error) {
+	if u.CBOR == nil {
+		return nil, fmt.Errorf("unknown value for discriminator %v has no CBOR payload", u.X)
+	}
+	return u.CBOR, nil
+}
+
+// UnmarshalCBOR keeps a copy of data as CBOR payload.
+func (u *Unknown[X]) UnmarshalCBOR(data []byte) error {
+	u.CBOR = append(cbor.RawMessage(nil), data...)
+	return nil
+}
+
+// MarshalBSON returns the BSON document unchanged.
+func (u Unknown[X]) MarshalBSON() ([]byte, error) {
+	if u.BSON == nil {
+		return nil, fmt.Errorf("unknown value for discriminator %v has no BSON payload", u.X)
+	}
+	return u.BSON, nil
+}
+
+// UnmarshalBSON keeps a copy of data as BSON document.
+func (u *Unknown[X]) UnmarshalBSON(data []byte) error {
+	u.BSON = append(bson.Raw(nil), data...)
+	return nil
+}
+
+// MarshalXML writes the attributes and content of the XML element unchanged, as element named by start.
+func (u Unknown[X]) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	if u.XML == nil {
+		return fmt.Errorf("unknown value for discriminator %v has no XML payload", u.X)
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(u.XML))
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if element, ok := tok.(xml.StartElement); ok {
+			n, err := readXML(d, element)
+			if err != nil {
+				return err
+			}
+			n.stripNamespaces()
+			n.start.Name = start.Name
+			return writeXML(e, n)
+		}
+	}
+}
+
+// UnmarshalXML keeps a copy of the element opened by start as XML element.
+func (u *Unknown[X]) UnmarshalXML(dec *xml.Decoder, start xml.StartElement) error {
+	n, err := readXML(dec, start)
+	if err != nil {
+		return err
+	}
+	n.stripNamespaces()
+
+	var buf bytes.Buffer
+	e := xml.NewEncoder(&buf)
+	if err := writeXML(e, n); err != nil {
+		return err
+	}
+	if err := e.Flush(); err != nil {
+		return err
+	}
+	u.XML = buf.Bytes()
+	return nil
+}
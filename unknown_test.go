@@ -0,0 +1,264 @@
+package ijson_test
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Nikkolix/ijson"
+)
+
+type UnknownPerson struct {
+	ijson.Unknown[UnmarshalDiscriminator]
+}
+
+func (u *UnknownPerson) GetType() string { return u.X.Type }
+
+type UnknownShape struct {
+	ijson.Unknown[string]
+}
+
+func (u *UnknownShape) Area() float64 { return 0 }
+
+type UnknownKind struct {
+	ijson.Unknown[string]
+}
+
+func (u *UnknownKind) Kind() string { return u.X }
+
+func registerUnknownPerson(t *testing.T) {
+	t.Helper()
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterT[PersonStruct, UnmarshalTestInterface](UnmarshalDiscriminator{Type: PersonType}))
+	require.NoError(t, ijson.RegisterDefault(func(x UnmarshalDiscriminator) UnmarshalTestInterface {
+		return &UnknownPerson{ijson.Unknown[UnmarshalDiscriminator]{X: x}}
+	}))
+}
+
+func TestRegisterDefault_Errors(t *testing.T) {
+	registerUnknownPerson(t)
+
+	err := ijson.RegisterDefault(func(x UnmarshalDiscriminator) UnmarshalTestInterface { return &UnknownPerson{} })
+	assert.EqualError(t, err, "default already registered for registry[I: ijson_test.UnmarshalTestInterface, X: ijson_test.UnmarshalDiscriminator]")
+
+	err = ijson.RegisterDefault(func(x string) any { return UnknownShape{} })
+	assert.EqualError(t, err, "factory must return a pointer type, got ijson_test.UnknownShape")
+
+	err = ijson.RegisterDefaultF[XFTestInterface, TestFSelector](func(x string) XFTestInterface { return &UnknownKind{} })
+	require.NoError(t, err)
+	err = ijson.RegisterDefaultF[XFTestInterface, TestFSelector](func(x string) XFTestInterface { return &UnknownKind{} })
+	assert.EqualError(t, err, "default already registered for registry[I: ijson_test.XFTestInterface, F: ijson_test.TestFSelector, X: string]")
+
+	err = ijson.RegisterDefaultF[any, TestFSelector](func(x string) any { return UnknownKind{} })
+	assert.EqualError(t, err, "factory must return a pointer type, got ijson_test.UnknownKind")
+}
+
+func TestRegisterDefault_JSON(t *testing.T) {
+	registerUnknownPerson(t)
+
+	data := []byte(`[{"type":"person","name":"Ann","age":3},{"type":"robot","model":"T1000","parts":[1,2]}]`)
+	var out []ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]
+	require.NoError(t, json.Unmarshal(data, &out))
+	require.Len(t, out, 2)
+	assert.Equal(t, &PersonStruct{Type: PersonType, Name: "Ann", Age: 3}, out[0].I)
+
+	unknown, ok := out[1].I.(*UnknownPerson)
+	require.True(t, ok)
+	assert.Equal(t, "robot", unknown.GetType())
+	assert.JSONEq(t, `{"type":"robot","model":"T1000","parts":[1,2]}`, string(unknown.JSON))
+
+	again, err := json.Marshal(out[1])
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"robot","model":"T1000","parts":[1,2]}`, string(again))
+
+	_, err = msgpack.Marshal(out[1])
+	assert.EqualError(t, err, "unknown value for discriminator {robot} has no msgpack payload")
+}
+
+func TestRegisterDefault_Msgpack(t *testing.T) {
+	registerUnknownPerson(t)
+
+	data, err := msgpack.Marshal(map[string]any{"type": "robot", "model": "T1000"})
+	require.NoError(t, err)
+
+	var d ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]
+	require.NoError(t, msgpack.Unmarshal(data, &d))
+	unknown, ok := d.I.(*UnknownPerson)
+	require.True(t, ok)
+	assert.Equal(t, UnmarshalDiscriminator{Type: "robot"}, unknown.X)
+
+	again, err := msgpack.Marshal(d)
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	_, err = json.Marshal(d)
+	assert.ErrorContains(t, err, "unknown value for discriminator {robot} has no JSON payload")
+}
+
+func TestRegisterDefaultF(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterF[XFTestInterface, TestFSelector]("A", func() XFTestInterface { return &XA{} }))
+	require.NoError(t, ijson.RegisterDefaultF[XFTestInterface, TestFSelector](func(x string) XFTestInterface {
+		return &UnknownKind{ijson.Unknown[string]{X: x}}
+	}))
+
+	data := []byte(`{"type":"Z","value":"abc"}`)
+	var d ijson.DecodableF[XFTestInterface, TestFSelector, string]
+	require.NoError(t, json.Unmarshal(data, &d))
+	assert.Equal(t, "Z", d.I.Kind())
+
+	again, err := json.Marshal(d)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+}
+
+func TestRegisterDefault_Adjacent(t *testing.T) {
+	registerShapes(t)
+	require.NoError(t, ijson.RegisterDefault(func(x string) Shape { return &UnknownShape{ijson.Unknown[string]{X: x}} }))
+
+	data := []byte(`{"type":"triangle","data":{"a":1,"b":2}}`)
+	var d ijson.RAdjacentDecodable[Shape, string, ijson.TypeData]
+	require.NoError(t, json.Unmarshal(data, &d))
+	assert.Equal(t, &UnknownShape{ijson.Unknown[string]{X: "triangle", JSON: json.RawMessage(`{"a":1,"b":2}`)}}, d.I)
+
+	again, err := json.Marshal(d)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+
+	packed, err := msgpack.Marshal(map[string]any{"triangle": map[string]int{"a": 1}})
+	require.NoError(t, err)
+	var e ijson.RExternalDecodable[Shape, string]
+	require.NoError(t, msgpack.Unmarshal(packed, &e))
+	assert.Equal(t, "triangle", e.I.(*UnknownShape).Tag())
+
+	repacked, err := msgpack.Marshal(e)
+	require.NoError(t, err)
+	assert.Equal(t, packed, repacked)
+}
+
+type Machine interface {
+	Model() string
+}
+
+type MachineKind struct {
+	Type string `json:"type" yaml:"type" cbor:"type" bson:"type"`
+}
+
+type UnknownMachine struct {
+	ijson.Unknown[MachineKind]
+}
+
+func (u *UnknownMachine) Model() string { return "" }
+
+func registerUnknownMachine(t *testing.T) {
+	t.Helper()
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterDefault(func(x MachineKind) Machine {
+		return &UnknownMachine{ijson.Unknown[MachineKind]{X: x}}
+	}))
+}
+
+func TestRegisterDefault_YAML(t *testing.T) {
+	registerUnknownMachine(t)
+
+	data := []byte("type: robot\nmodel: T1000\nparts: [1, 2]\n")
+	var d ijson.RDecodable[Machine, MachineKind]
+	require.NoError(t, yaml.Unmarshal(data, &d))
+	unknown, ok := d.I.(*UnknownMachine)
+	require.True(t, ok)
+	assert.Equal(t, MachineKind{Type: "robot"}, unknown.X)
+	require.NotNil(t, unknown.YAML)
+
+	again, err := yaml.Marshal(d)
+	require.NoError(t, err)
+	assert.Equal(t, "type: robot\nmodel: T1000\nparts: [1, 2]\n", string(again))
+
+	_, err = cbor.Marshal(d)
+	assert.ErrorContains(t, err, "unknown value for discriminator {robot} has no CBOR payload")
+}
+
+func TestRegisterDefault_CBOR(t *testing.T) {
+	registerUnknownMachine(t)
+
+	data, err := cbor.Marshal(struct {
+		Type  string `cbor:"type"`
+		Model string `cbor:"model"`
+	}{Type: "robot", Model: "T1000"})
+	require.NoError(t, err)
+
+	var d ijson.RDecodable[Machine, MachineKind]
+	require.NoError(t, cbor.Unmarshal(data, &d))
+	assert.Equal(t, MachineKind{Type: "robot"}, d.I.(*UnknownMachine).X)
+
+	again, err := cbor.Marshal(d)
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	_, err = yaml.Marshal(d)
+	assert.ErrorContains(t, err, "unknown value for discriminator {robot} has no YAML payload")
+}
+
+func TestRegisterDefault_BSON(t *testing.T) {
+	registerUnknownMachine(t)
+
+	data, err := bson.Marshal(bson.D{{Key: "type", Value: "robot"}, {Key: "model", Value: "T1000"}})
+	require.NoError(t, err)
+
+	var d ijson.RDecodable[Machine, MachineKind]
+	require.NoError(t, bson.Unmarshal(data, &d))
+	assert.Equal(t, MachineKind{Type: "robot"}, d.I.(*UnknownMachine).X)
+
+	again, err := bson.Marshal(d)
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	_, err = json.Marshal(d)
+	assert.ErrorContains(t, err, "unknown value for discriminator {robot} has no JSON payload")
+}
+
+type UnknownXMLShape struct {
+	ijson.Unknown[XSIType]
+}
+
+func (u *UnknownXMLShape) Area() float64 { return 0 }
+
+func TestRegisterDefault_XML(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterT[XMLCircle, XMLShape](XSIType{Type: "circle"}))
+	require.NoError(t, ijson.RegisterDefault(func(x XSIType) XMLShape {
+		return &UnknownXMLShape{ijson.Unknown[XSIType]{X: x}}
+	}))
+
+	type Drawing struct {
+		XMLName xml.Name                              `xml:"drawing"`
+		Shapes  []ijson.RDecodable[XMLShape, XSIType] `xml:"shape"`
+	}
+
+	data := `<drawing xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		`<shape xsi:type="circle"><radius>1</radius></shape>` +
+		`<shape xsi:type="triangle" sides="3"><a>1</a><b>2</b></shape>` +
+		`</drawing>`
+	var drawing Drawing
+	require.NoError(t, xml.Unmarshal([]byte(data), &drawing))
+	require.Len(t, drawing.Shapes, 2)
+	unknown, ok := drawing.Shapes[1].I.(*UnknownXMLShape)
+	require.True(t, ok)
+	assert.Equal(t, XSIType{Type: "triangle"}, unknown.X)
+
+	again, err := xml.Marshal(drawing)
+	require.NoError(t, err)
+	assert.Equal(t, `<drawing>`+
+		`<shape xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="circle"><radius>1</radius></shape>`+
+		`<shape xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="triangle" sides="3"><a>1</a><b>2</b></shape>`+
+		`</drawing>`, string(again))
+
+	_, err = json.Marshal(drawing.Shapes[1])
+	assert.ErrorContains(t, err, "unknown value for discriminator {triangle} has no JSON payload")
+}
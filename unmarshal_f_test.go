@@ -1,6 +1,7 @@
 package ijson_test
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/Nikkolix/ijson"
@@ -71,7 +72,7 @@ func TestDecodableXF_UnmarshalJSON_MissingDiscriminator(t *testing.T) {
 	err := d.UnmarshalJSON([]byte(`{"value":"x"}`))
 
 	require.Error(t, err)
-	assert.Equal(t, "discriminator field type not found in map map[value:x]", err.Error())
+	assert.Equal(t, "json: decide ijson_test.XFTestInterface for X value type=<missing>: discriminator field type not found", err.Error())
 }
 
 func TestDecodableXF_UnmarshalJSON_NoRegistryEntry(t *testing.T) {
@@ -81,5 +82,158 @@ func TestDecodableXF_UnmarshalJSON_NoRegistryEntry(t *testing.T) {
 	err := d.UnmarshalJSON([]byte(`{"type":"Z","value":"x"}`))
 
 	require.Error(t, err)
-	assert.Equal(t, "no factory found in registry[I: ijson_test.XFTestInterface, F: ijson_test.TestFSelector, X: string] and X value Z", err.Error())
+	assert.Equal(t, "json: decide ijson_test.XFTestInterface: no factory found in registry[I: ijson_test.XFTestInterface, F: ijson_test.TestFSelector, X: string] and X value Z", err.Error())
+}
+
+func TestDecodableXF_Unmarshal_MixedTypes(t *testing.T) {
+	ijson.ResetRegistries()
+
+	require.NoError(t, ijson.RegisterF[XFTestInterface, TestFSelector]("A", func() XFTestInterface { return &XA{} }))
+
+	var d ijson.DecodableF[XFTestInterface, TestFSelector, string]
+	err := json.Unmarshal([]byte(`{"count":3,"nested":{"type":"B","list":[1,true]},"type":"A","value":"hello","ok":null}`), &d)
+	require.NoError(t, err)
+	assert.Equal(t, &XA{Type: "A", Value: "hello"}, d.I)
+
+	raw, err := msgpack.Marshal(map[string]any{"count": 3, "nested": map[string]any{"list": []any{1, true}}, "type": "A", "value": "v"})
+	require.NoError(t, err)
+	err = msgpack.Unmarshal(raw, &d)
+	require.NoError(t, err)
+	assert.Equal(t, &XA{Type: "A", Value: "v"}, d.I)
+}
+
+func TestFValue(t *testing.T) {
+	type selector = ijson.FValue[TestFSelector, int]
+
+	var v selector
+	require.NoError(t, json.Unmarshal([]byte(`{"Type":1,"other":"x","type":2}`), &v))
+	assert.Equal(t, selector{X: 2, Found: true}, v)
+	assert.Equal(t, "type=2", v.String())
+
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":2}`, string(data))
+
+	require.NoError(t, json.Unmarshal([]byte(`null`), &v))
+	assert.Equal(t, selector{}, v)
+	assert.Equal(t, "type=<missing>", v.String())
+
+	data, err = json.Marshal(v)
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(data))
+
+	assert.Error(t, json.Unmarshal([]byte(`[1]`), &v))
+	assert.Error(t, json.Unmarshal([]byte(`{"type":"x"}`), &v))
+	assert.Error(t, v.UnmarshalJSON([]byte(`{"type":1} {}`)))
+
+	packed, err := msgpack.Marshal(selector{X: 3, Found: true})
+	require.NoError(t, err)
+	require.NoError(t, msgpack.Unmarshal(packed, &v))
+	assert.Equal(t, selector{X: 3, Found: true}, v)
+
+	packed, err = msgpack.Marshal(selector{})
+	require.NoError(t, err)
+	require.NoError(t, msgpack.Unmarshal(packed, &v))
+	assert.Equal(t, selector{}, v)
+
+	require.NoError(t, v.UnmarshalMsgpack([]byte{0xc0}))
+	assert.Equal(t, selector{}, v)
+
+	packed, err = msgpack.Marshal(map[string]string{"type": "x"})
+	require.NoError(t, err)
+	assert.Error(t, v.UnmarshalMsgpack(packed))
+	assert.Error(t, v.UnmarshalMsgpack([]byte{0x91, 0x01}))
+}
+
+type MetadataKind struct{}
+
+func (MetadataKind) FieldName() string { return "/metadata/kind" }
+
+type HeaderType struct{}
+
+func (HeaderType) FieldName() string { return "/header/type" }
+
+type Resource struct {
+	Metadata struct {
+		Name string `json:"name" msgpack:"name"`
+	} `json:"metadata" msgpack:"metadata"`
+	Replicas int `json:"replicas" msgpack:"replicas"`
+}
+
+func (r *Resource) Kind() string { return "Deployment" }
+
+func TestDecodableXF_NestedPath(t *testing.T) {
+	ijson.ResetRegistries()
+
+	require.NoError(t, ijson.RegisterF[XFTestInterface, MetadataKind]("Deployment", func() XFTestInterface { return &Resource{} }))
+	require.NoError(t, ijson.RegisterF[XFTestInterface, HeaderType](1, func() XFTestInterface { return &Resource{} }))
+
+	var d ijson.DecodableF[XFTestInterface, MetadataKind, string]
+	err := json.Unmarshal([]byte(`{"replicas":2,"metadata":{"name":"web","kind":"Deployment"}}`), &d)
+	require.NoError(t, err)
+	want := &Resource{Replicas: 2}
+	want.Metadata.Name = "web"
+	assert.Equal(t, want, d.I)
+
+	data, err := json.Marshal(d)
+	require.NoError(t, err)
+	assert.Equal(t, `{"metadata":{"kind":"Deployment","name":"web"},"replicas":2}`, string(data))
+
+	packed, err := msgpack.Marshal(d)
+	require.NoError(t, err)
+	var m ijson.DecodableF[XFTestInterface, MetadataKind, string]
+	require.NoError(t, msgpack.Unmarshal(packed, &m))
+	assert.Equal(t, want, m.I)
+
+	var h ijson.DecodableF[XFTestInterface, HeaderType, int]
+	require.NoError(t, json.Unmarshal([]byte(`{"header":{"type":1},"replicas":3}`), &h))
+	assert.Equal(t, &Resource{Replicas: 3}, h.I)
+
+	data, err = json.Marshal(h)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"header":{"type":1},"metadata":{"name":""},"replicas":3}`, string(data))
+
+	packed, err = msgpack.Marshal(h)
+	require.NoError(t, err)
+	require.NoError(t, msgpack.Unmarshal(packed, &h))
+	assert.Equal(t, &Resource{Replicas: 3}, h.I)
+
+	err = json.Unmarshal([]byte(`{"metadata":"Deployment"}`), &d)
+	var missing *ijson.MissingFieldError
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, "/metadata/kind", missing.Field)
+
+	err = json.Unmarshal([]byte(`{"metadata":{"name":"web"}}`), &d)
+	require.ErrorAs(t, err, &missing)
+
+	packed, err = msgpack.Marshal(map[string]any{"header": "x"})
+	require.NoError(t, err)
+	require.ErrorAs(t, msgpack.Unmarshal(packed, &h), &missing)
+	assert.Equal(t, "/header/type", missing.Field)
+
+	packed, err = msgpack.Marshal(map[string]any{"header": map[string]any{}})
+	require.NoError(t, err)
+	require.ErrorAs(t, msgpack.Unmarshal(packed, &h), &missing)
+}
+
+type DottedKind struct{}
+
+func (DottedKind) FieldName() string { return "metadata.kind" }
+
+func TestDecodableXF_DottedKey(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterF[XFTestInterface, DottedKind]("Deployment", func() XFTestInterface { return &Resource{} }))
+
+	var d ijson.DecodableF[XFTestInterface, DottedKind, string]
+	require.NoError(t, json.Unmarshal([]byte(`{"metadata.kind":"Deployment","replicas":2}`), &d))
+	assert.Equal(t, &Resource{Replicas: 2}, d.I)
+
+	data, err := json.Marshal(d)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"metadata.kind":"Deployment","metadata":{"name":""},"replicas":2}`, string(data))
+
+	err = json.Unmarshal([]byte(`{"metadata":{"kind":"Deployment"}}`), &d)
+	var missing *ijson.MissingFieldError
+	require.ErrorAs(t, err, &missing)
+	assert.Equal(t, "metadata.kind", missing.Field)
 }
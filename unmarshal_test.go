@@ -1,6 +1,7 @@
 package ijson_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -251,6 +252,17 @@ func TestRDecodable_UnmarshalMsgpack(t *testing.T) {
 	assert.IsType(t, &SA{}, s.I.I)
 }
 
+func TestRDecodable_UnmarshalMsgpack_BinKeys(t *testing.T) {
+	registerPets(t)
+
+	// {bin("type"): "cat", bin("lives"): 3}
+	data := []byte("\x82\xc4\x04type\xa3cat\xc4\x05lives\x03")
+
+	var d ijson.RDecodable[Pet, PetKind]
+	require.NoError(t, msgpack.Unmarshal(data, &d))
+	assert.Equal(t, &Cat{Lives: 3}, d.I)
+}
+
 func TestXDecodable_UnmarshalJson(t *testing.T) {
 	type S struct {
 		I ijson.XDecodable[I, XDeciderImpl]
@@ -324,7 +336,7 @@ func TestDecodable_UnmarshalJSON_InvalidJSON(t *testing.T) {
 
 	err := decodable.UnmarshalJSON([]byte(invalidJSON))
 	require.Error(t, err)
-	assert.Equal(t, "invalid character 'i' looking for beginning of value", err.Error())
+	assert.Equal(t, "json: decode discriminator ijson_test.UnmarshalDiscriminator: invalid character 'i' looking for beginning of value", err.Error())
 }
 
 func TestDecodable_UnmarshalJSON_NoRegisteredType(t *testing.T) {
@@ -335,7 +347,7 @@ func TestDecodable_UnmarshalJSON_NoRegisteredType(t *testing.T) {
 
 	err := decodable.UnmarshalJSON([]byte(jsonData))
 	require.Error(t, err)
-	assert.Equal(t, "no factory found in registry[I: ijson_test.UnmarshalTestInterface, X: ijson_test.UnmarshalDiscriminator] and X value {unknown}", err.Error())
+	assert.Equal(t, "json: decide ijson_test.UnmarshalTestInterface: no factory found in registry[I: ijson_test.UnmarshalTestInterface, X: ijson_test.UnmarshalDiscriminator] and X value {unknown}", err.Error())
 }
 
 func TestDecodable_UnmarshalJSON_NoRegistry(t *testing.T) {
@@ -353,7 +365,7 @@ func TestDecodable_UnmarshalJSON_NoRegistry(t *testing.T) {
 
 	err := decodable.UnmarshalJSON([]byte(jsonData))
 	require.Error(t, err)
-	assert.Equal(t, "no factory found in registry[I: ijson_test.UnknownInterface, X: ijson_test.UnknownDiscriminator] and X value {test}", err.Error())
+	assert.Equal(t, "json: decide ijson_test.UnknownInterface: no factory found in registry[I: ijson_test.UnknownInterface, X: ijson_test.UnknownDiscriminator] and X value {test}", err.Error())
 }
 
 func TestDecodable_UnmarshalJSON_ComplexStructure(t *testing.T) {
@@ -387,7 +399,7 @@ func TestDecodable_UnmarshalJSON_EmptyJSON(t *testing.T) {
 	err := decodable.UnmarshalJSON([]byte("{}"))
 
 	require.Error(t, err)
-	assert.Equal(t, "no factory found in registry[I: ijson_test.UnmarshalTestInterface, X: ijson_test.UnmarshalDiscriminator] and X value {}", err.Error())
+	assert.Equal(t, "json: decide ijson_test.UnmarshalTestInterface: no factory found in registry[I: ijson_test.UnmarshalTestInterface, X: ijson_test.UnmarshalDiscriminator] and X value {}", err.Error())
 }
 
 func TestDecodable_UnmarshalJSON_WithXDecodable(t *testing.T) {
@@ -450,7 +462,7 @@ func TestDecodable_UnmarshalMsgpack_InvalidData(t *testing.T) {
 
 	err := decodable.UnmarshalMsgpack(invalidMsgpack)
 	require.Error(t, err)
-	assert.Equal(t, "msgpack: unexpected code=ff decoding map length", err.Error())
+	assert.Equal(t, "msgpack: decode discriminator ijson_test.UnmarshalDiscriminator: msgpack: unexpected code=ff decoding map length", err.Error())
 }
 
 func TestDecodable_UnmarshalMsgpack_NoRegisteredType(t *testing.T) {
@@ -463,7 +475,7 @@ func TestDecodable_UnmarshalMsgpack_NoRegisteredType(t *testing.T) {
 	var decodable ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]
 	err = decodable.UnmarshalMsgpack(msgpackData)
 	require.Error(t, err)
-	assert.Equal(t, "no factory found in registry[I: ijson_test.UnmarshalTestInterface, X: ijson_test.UnmarshalDiscriminator] and X value {unknown}", err.Error())
+	assert.Equal(t, "msgpack: decide ijson_test.UnmarshalTestInterface: no factory found in registry[I: ijson_test.UnmarshalTestInterface, X: ijson_test.UnmarshalDiscriminator] and X value {unknown}", err.Error())
 }
 
 func TestDecodable_UnmarshalMsgpack_ComplexStructure(t *testing.T) {
@@ -517,7 +529,7 @@ func TestDecodable_UnmarshalMsgpack_EmptyData(t *testing.T) {
 	var decodable ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]
 	err := decodable.UnmarshalMsgpack(nilMsgpack)
 	require.Error(t, err)
-	assert.Equal(t, "no factory found in registry[I: ijson_test.UnmarshalTestInterface, X: ijson_test.UnmarshalDiscriminator] and X value {}", err.Error())
+	assert.Equal(t, "msgpack: decide ijson_test.UnmarshalTestInterface: no factory found in registry[I: ijson_test.UnmarshalTestInterface, X: ijson_test.UnmarshalDiscriminator] and X value {}", err.Error())
 }
 
 func TestDecodable_UnmarshalMsgpack_LargeData(t *testing.T) {
@@ -564,14 +576,14 @@ func TestDecodable_Unmarshal_DeciderError(t *testing.T) {
 	var jsonDecodable ijson.XDecodable[UnmarshalTestInterface, ErrorDeciderStruct]
 	err := jsonDecodable.UnmarshalJSON([]byte(jsonData))
 	require.Error(t, err)
-	assert.Equal(t, "intentional decider error", err.Error())
+	assert.Equal(t, "json: decide ijson_test.UnmarshalTestInterface for X value {true}: intentional decider error", err.Error())
 
 	msgpackData, err := msgpack.Marshal(ErrorDeciderStruct{ShouldError: true})
 	assert.NoError(t, err)
 	var msgpackDecodable ijson.XDecodable[UnmarshalTestInterface, ErrorDeciderStruct]
 	err = msgpackDecodable.UnmarshalMsgpack(msgpackData)
 	require.Error(t, err)
-	assert.Equal(t, "intentional decider error", err.Error())
+	assert.Equal(t, "msgpack: decide ijson_test.UnmarshalTestInterface for X value {true}: intentional decider error", err.Error())
 }
 
 func TestDecodable_Unmarshal_SecondUnmarshalFails(t *testing.T) {
@@ -585,5 +597,100 @@ func TestDecodable_Unmarshal_SecondUnmarshalFails(t *testing.T) {
 	var decodable ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]
 	err = decodable.UnmarshalJSON([]byte(invalidStructureJSON))
 	require.Error(t, err)
-	assert.Equal(t, "json: cannot unmarshal object into Go struct field InconsistentStruct.data of type string", err.Error())
+	assert.Equal(t, "json: decode *ijson_test.InconsistentStruct for X value {inconsistent}: json: cannot unmarshal object into Go struct field InconsistentStruct.data of type string", err.Error())
+}
+
+func TestDecodable_DecodeMsgpack_Nested(t *testing.T) {
+	ijson.ResetRegistries()
+
+	err := ijson.RegisterT[PersonStruct, UnmarshalTestInterface](UnmarshalDiscriminator{Type: PersonType})
+	assert.NoError(t, err)
+	err = ijson.RegisterT[AnimalStruct, UnmarshalTestInterface](UnmarshalDiscriminator{Type: AnimalType})
+	assert.NoError(t, err)
+
+	type Group struct {
+		Members []ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator] `msgpack:"members"`
+	}
+	type Document struct {
+		Groups map[string]Group `msgpack:"groups"`
+	}
+
+	in := Document{Groups: map[string]Group{
+		"g": {Members: []ijson.RDecodable[UnmarshalTestInterface, UnmarshalDiscriminator]{
+			{I: &PersonStruct{Name: "Jane", Age: 25}},
+			{I: &AnimalStruct{Species: "Cat", Sound: "Meow"}},
+		}},
+	}}
+
+	data, err := msgpack.Marshal(in)
+	require.NoError(t, err)
+
+	var out Document
+	err = msgpack.Unmarshal(data, &out)
+	require.NoError(t, err)
+
+	members := out.Groups["g"].Members
+	require.Len(t, members, 2)
+	assert.Equal(t, &PersonStruct{Name: "Jane", Age: 25, Type: PersonType}, members[0].I)
+	assert.Equal(t, &AnimalStruct{Species: "Cat", Sound: "Meow", Type: AnimalType}, members[1].I)
+}
+
+type Parrot struct {
+	Words string `json:"words"`
+}
+
+func (*Parrot) Sound() string { return "squawk" }
+
+func TestDecodable_EncodeMsgpack_EncoderSettings(t *testing.T) {
+	registerPets(t)
+	require.NoError(t, ijson.RegisterT[Parrot, Pet](PetKind{Type: "parrot"}))
+
+	encode := func(v any) map[string]any {
+		t.Helper()
+		var buf bytes.Buffer
+		enc := msgpack.NewEncoder(&buf)
+		enc.SetCustomStructTag("json")
+		require.NoError(t, enc.Encode(v))
+
+		var out map[string]any
+		require.NoError(t, msgpack.Unmarshal(buf.Bytes(), &out))
+		return out
+	}
+
+	parrot := &Parrot{Words: "hello"}
+	assert.Equal(t, map[string]any{"type": "parrot", "words": "hello"},
+		encode(map[string]any{"v": ijson.RDecodable[Pet, PetKind]{I: parrot}})["v"])
+	assert.Equal(t, []any{map[string]any{"type": "parrot", "words": "hello"}},
+		encode(map[string]any{"v": ijson.RDecodableSlice[Pet, PetKind, ijson.Strict]{parrot}})["v"])
+	assert.Equal(t, map[string]any{"a": map[string]any{"type": "parrot", "words": "hello"}},
+		encode(map[string]any{"v": ijson.RDecodableMap[string, Pet, PetKind, ijson.Strict]{"a": parrot}})["v"])
+	assert.Equal(t, map[string]any{"type": map[string]any{"type": "parrot"}, "data": map[string]any{"words": "hello"}},
+		encode(map[string]any{"v": ijson.RAdjacentDecodable[Pet, PetKind, ijson.TypeData]{I: parrot}})["v"])
+}
+
+func TestDecodable_EncodeMsgpack_WithoutDiscriminator(t *testing.T) {
+	type S struct {
+		I ijson.XDecodable[UnmarshalTestInterface, SelfMsgpackStruct] `msgpack:"i"`
+	}
+
+	data, err := msgpack.Marshal(S{I: ijson.XDecodable[UnmarshalTestInterface, SelfMsgpackStruct]{I: &SelfMsgpackStruct{Value: "v", Count: 1}}})
+	require.NoError(t, err)
+
+	var out S
+	err = msgpack.Unmarshal(data, &out)
+	require.NoError(t, err)
+	assert.Equal(t, &SelfMsgpackStruct{Value: "v", Count: 1}, out.I.I)
+}
+
+type DashKind struct {
+	Kind string `json:"-,"`
+}
+
+func TestDecodable_UnmarshalJSON_DashKey(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterT[Dog, Pet](DashKind{Kind: "dog"}))
+
+	var d ijson.RDecodable[Pet, DashKind]
+	require.NoError(t, json.Unmarshal([]byte(`{"-":"dog","name":"Rex"}`), &d))
+	assert.Equal(t, &Dog{Name: "Rex"}, d.I)
 }
@@ -0,0 +1,256 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+var (
+	_ json.Marshaler   = UntaggedDecodable[any, DefaultScope]{}
+	_ json.Unmarshaler = &UntaggedDecodable[any, DefaultScope]{}
+
+	_ msgpack.Marshaler     = UntaggedDecodable[any, DefaultScope]{}
+	_ msgpack.Unmarshaler   = &UntaggedDecodable[any, DefaultScope]{}
+	_ msgpack.CustomEncoder = UntaggedDecodable[any, DefaultScope]{}
+	_ msgpack.CustomDecoder = &UntaggedDecodable[any, DefaultScope]{}
+)
+
+// candidatesKey is a unique key to get the untagged candidates for type I
+type candidatesKey[I any] struct{}
+
+// RegisterUntagged registers a factory function as untagged candidate for interface I in the default registry.
+// Candidates are tried in registration order.
+func RegisterUntagged[I any](factory func() I) error {
+	return RegisterUntaggedIn(defaultRegistry, factory)
+}
+
+// RegisterUntaggedIn registers a factory function as untagged candidate for interface I in registry r.
+// Candidates are tried in registration order.
+func RegisterUntaggedIn[I any](r *Registry, factory func() I) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.Frozen() {
+		return ErrFrozen
+	}
+
+	t := factory()
+	if err := checkFactory[I](t); err != nil {
+		return err
+	}
+
+	key := reflect.TypeFor[candidatesKey[I]]()
+	candidates, _ := r.tables[key].([]func() I)
+	for _, candidate := range candidates {
+		if reflect.TypeOf(candidate()) == reflect.TypeOf(t) {
+			return &DuplicateError{Interface: reflect.TypeFor[I](), Type: reflect.TypeOf(t)}
+		}
+	}
+
+	r.set(key, append(slices.Clip(candidates), factory))
+	return nil
+}
+
+// CandidateError describes why an untagged candidate was rejected.
+type CandidateError struct {
+	Type reflect.Type // The concrete type of the candidate
+	Err  error        // The reason the payload did not fit the candidate
+}
+
+// Error returns the type of the candidate and the reason it was rejected.
+func (e CandidateError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Type, e.Err)
+}
+
+// Unwrap returns the reason the candidate was rejected.
+func (e CandidateError) Unwrap() error {
+	return e.Err
+}
+
+// UntaggedError is returned when no untagged candidate matches a payload.
+type UntaggedError struct {
+	Interface  reflect.Type     // The interface type I
+	Candidates []CandidateError // The rejected candidates in the order they were tried
+}
+
+// Error lists every candidate with the reason it was rejected.
+func (e *UntaggedError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "no untagged candidate matched for I type %s", e.Interface)
+	for _, c := range e.Candidates {
+		sb.WriteString("; ")
+		sb.WriteString(c.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap returns the errors of all rejected candidates.
+func (e *UntaggedError) Unwrap() []error {
+	errs := make([]error, len(e.Candidates))
+	for i, c := range e.Candidates {
+		errs[i] = c
+	}
+	return errs
+}
+
+// UntaggedDecodable is a generic wrapper for polymorphic (de)serialization of payloads without a discriminator.
+// I is the interface type and S selects the registry holding the candidates.
+// The payload is decoded strictly into each candidate registered with RegisterUntagged in registration order
+// and the first candidate that fits is used. A candidate fits if the payload has no unknown fields
+// and contains every field tagged with `ijson:"required"`.
+type UntaggedDecodable[I any, S Scope] struct {
+	I I // The decoded value implementing I
+}
+
+// UDecodable is a type alias for UntaggedDecodable using the default registry.
+type UDecodable[I any] = UntaggedDecodable[I, DefaultScope]
+
+// MarshalJSON marshals the contained value using JSON.
+func (d UntaggedDecodable[I, S]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.I)
+}
+
+// UnmarshalJSON does unmarshal data into the first matching candidate using JSON.
+func (d *UntaggedDecodable[I, S]) UnmarshalJSON(data []byte) error {
+	if isJSONNull(data) {
+		var i I
+		d.I = i
+		return nil
+	}
+
+	fields, _ := splitJSONObject(data)
+	keys := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if key, ok := unquoteJSONKey(f.key); ok {
+			keys = append(keys, key)
+		}
+	}
+
+	return d.match(func(i I) error {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(i); err != nil {
+			return err
+		}
+		return checkRequired(reflect.TypeOf(i), "json", keys, strings.EqualFold)
+	})
+}
+
+// MarshalMsgpack marshals the contained value using msgpack.
+func (d UntaggedDecodable[I, S]) MarshalMsgpack() ([]byte, error) {
+	return msgpack.Marshal(d.I)
+}
+
+// EncodeMsgpack encodes the contained value to the msgpack encoder.
+func (d UntaggedDecodable[I, S]) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.Encode(d.I)
+}
+
+// DecodeMsgpack decodes the next value of the msgpack decoder into the first matching candidate.
+func (d *UntaggedDecodable[I, S]) DecodeMsgpack(dec *msgpack.Decoder) error {
+	data, err := dec.DecodeRaw()
+	if err != nil {
+		return err
+	}
+	return d.UnmarshalMsgpack(data)
+}
+
+// UnmarshalMsgpack does unmarshal data into the first matching candidate using msgpack.
+func (d *UntaggedDecodable[I, S]) UnmarshalMsgpack(data []byte) error {
+	if isMsgpackNil(data) {
+		var i I
+		d.I = i
+		return nil
+	}
+
+	fields, _ := splitMsgpackMap(data)
+	keys := make([]string, 0, len(fields))
+	for _, f := range fields {
+		s := msgpackScanner{data: f.key}
+		if key, err := s.str(); err == nil {
+			keys = append(keys, string(key))
+		}
+	}
+
+	return d.match(func(i I) error {
+		dec := msgpack.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields(true)
+		if err := dec.Decode(i); err != nil {
+			return err
+		}
+		return checkRequired(reflect.TypeOf(i), "msgpack", keys, func(a, b string) bool { return a == b })
+	})
+}
+
+// match decodes into each candidate until decode succeeds.
+func (d *UntaggedDecodable[I, S]) match(decode func(I) error) error {
+	r := (*new(S)).Registry()
+	candidates, _ := r.get(reflect.TypeFor[candidatesKey[I]]()).([]func() I)
+
+	rejected := make([]CandidateError, 0, len(candidates))
+	for _, factory := range candidates {
+		i := factory()
+		err := decode(i)
+		if err == nil {
+			d.I = i
+			return nil
+		}
+		rejected = append(rejected, CandidateError{Type: reflect.TypeOf(i), Err: err})
+	}
+	return &UntaggedError{Interface: reflect.TypeFor[I](), Candidates: rejected}
+}
+
+// checkRequired returns an error if a field of the struct t points to that is tagged `ijson:"required"`
+// has no matching key in keys. The name of a field is taken from the codec tag or the field name.
+func checkRequired(t reflect.Type, codecTag string, keys []string, equal func(a, b string) bool) error {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for _, f := range reflect.VisibleFields(t) {
+		if !hasTagOption(f.Tag.Get("ijson"), "required") {
+			continue
+		}
+
+		name, _, _ := strings.Cut(f.Tag.Get(codecTag), ",")
+		if name == "" {
+			name = f.Name
+		}
+
+		found := false
+		for _, key := range keys {
+			if equal(key, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("required field %s not found", name)
+		}
+	}
+	return nil
+}
+
+// hasTagOption reports whether the comma separated tag contains option.
+func hasTagOption(tag, option string) bool {
+	for tag != "" {
+		var name string
+		name, tag, _ = strings.Cut(tag, ",")
+		if name == option {
+			return true
+		}
+	}
+	return false
+}
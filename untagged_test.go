@@ -0,0 +1,167 @@
+package ijson_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/Nikkolix/ijson"
+)
+
+type Point struct {
+	X int `json:"x" msgpack:"x" ijson:"required"`
+	Y int `json:"y" msgpack:"y" ijson:"required"`
+}
+
+func (p *Point) Area() float64 { return 0 }
+
+type Rect struct {
+	W     int    `json:"w" msgpack:"w" ijson:"required"`
+	H     int    `json:"h" msgpack:"h" ijson:"required"`
+	Label string `json:"label,omitempty" msgpack:"label,omitempty"`
+}
+
+func (r *Rect) Area() float64 { return float64(r.W * r.H) }
+
+type Blob struct {
+	Label string `json:"label" msgpack:"label"`
+}
+
+func (b *Blob) Area() float64 { return 0 }
+
+func registerUntagged(t *testing.T) {
+	t.Helper()
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterUntagged[Shape](func() Shape { return &Point{} }))
+	require.NoError(t, ijson.RegisterUntagged[Shape](func() Shape { return &Rect{} }))
+	require.NoError(t, ijson.RegisterUntagged[Shape](func() Shape { return &Blob{} }))
+}
+
+func TestRegisterUntagged_Errors(t *testing.T) {
+	registerUntagged(t)
+
+	err := ijson.RegisterUntagged[Shape](func() Shape { return &Rect{} })
+	assert.EqualError(t, err, "type *ijson_test.Rect already registered as untagged candidate for I type ijson_test.Shape")
+
+	err = ijson.RegisterUntagged[any](func() any { return Rect{} })
+	assert.EqualError(t, err, "factory must return a pointer type, got ijson_test.Rect")
+}
+
+func TestUntaggedDecodable_UnmarshalJSON(t *testing.T) {
+	registerUntagged(t)
+
+	tests := []struct {
+		data string
+		want Shape
+	}{
+		{`{"x":1,"y":2}`, &Point{X: 1, Y: 2}},
+		{`{"w":2,"h":3}`, &Rect{W: 2, H: 3}},
+		{`{"W":2,"H":3,"label":"r"}`, &Rect{W: 2, H: 3, Label: "r"}},
+		{`{"label":"b"}`, &Blob{Label: "b"}},
+		{`{}`, &Blob{}},
+		{`null`, nil},
+	}
+	for _, tt := range tests {
+		var d ijson.UDecodable[Shape]
+		require.NoError(t, json.Unmarshal([]byte(tt.data), &d), tt.data)
+		assert.Equal(t, tt.want, d.I, tt.data)
+	}
+}
+
+func TestUntaggedDecodable_UnmarshalJSON_NoMatch(t *testing.T) {
+	registerUntagged(t)
+
+	var d ijson.UDecodable[Shape]
+	err := json.Unmarshal([]byte(`{"x":1,"w":2}`), &d)
+	require.Error(t, err)
+
+	var untagged *ijson.UntaggedError
+	require.ErrorAs(t, err, &untagged)
+	assert.Equal(t, "ijson_test.Shape", untagged.Interface.String())
+	require.Len(t, untagged.Candidates, 3)
+	assert.Equal(t, "*ijson_test.Point", untagged.Candidates[0].Type.String())
+	assert.ErrorContains(t, untagged.Candidates[0].Err, "w")
+	assert.Equal(t, "*ijson_test.Rect", untagged.Candidates[1].Type.String())
+	assert.ErrorContains(t, untagged.Candidates[1].Err, "x")
+	assert.ErrorContains(t, err, "no untagged candidate matched for I type ijson_test.Shape; *ijson_test.Point: ")
+
+	err = json.Unmarshal([]byte(`{"x":1}`), &d)
+	require.ErrorAs(t, err, &untagged)
+	assert.EqualError(t, untagged.Candidates[0], "*ijson_test.Point: required field y not found")
+	assert.True(t, errors.Is(err, untagged.Candidates[0].Err))
+
+	err = json.Unmarshal([]byte(`[1]`), &d)
+	assert.ErrorAs(t, err, &untagged)
+
+	var empty ijson.UDecodable[TestInterface]
+	err = json.Unmarshal([]byte(`{}`), &empty)
+	assert.EqualError(t, err, "no untagged candidate matched for I type ijson_test.TestInterface")
+}
+
+func TestUntaggedDecodable_MarshalJSON(t *testing.T) {
+	registerUntagged(t)
+
+	data, err := json.Marshal(ijson.UDecodable[Shape]{I: &Rect{W: 1, H: 2}})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"w":1,"h":2}`, string(data))
+
+	var d ijson.UDecodable[Shape]
+	require.NoError(t, json.Unmarshal(data, &d))
+	assert.Equal(t, &Rect{W: 1, H: 2}, d.I)
+}
+
+func TestUntaggedDecodable_Msgpack(t *testing.T) {
+	registerUntagged(t)
+
+	in := []ijson.UDecodable[Shape]{{I: &Point{X: 1, Y: 2}}, {I: &Rect{W: 2, H: 3}}, {I: &Blob{Label: "b"}}, {}}
+	data, err := msgpack.Marshal(in)
+	require.NoError(t, err)
+
+	var out []ijson.UDecodable[Shape]
+	require.NoError(t, msgpack.Unmarshal(data, &out))
+	assert.Equal(t, in, out)
+
+	single, err := ijson.UDecodable[Shape]{I: &Rect{W: 4, H: 5}}.MarshalMsgpack()
+	require.NoError(t, err)
+	var d ijson.UDecodable[Shape]
+	require.NoError(t, msgpack.Unmarshal(single, &d))
+	assert.Equal(t, &Rect{W: 4, H: 5}, d.I)
+
+	// msgpack keys are matched exactly
+	data, err = msgpack.Marshal(map[string]int{"W": 2, "H": 3})
+	require.NoError(t, err)
+	var untagged *ijson.UntaggedError
+	require.ErrorAs(t, msgpack.Unmarshal(data, &d), &untagged)
+	require.Len(t, untagged.Candidates, 3)
+
+	data, err = msgpack.Marshal(map[string]int{"x": 1})
+	require.NoError(t, err)
+	require.ErrorAs(t, msgpack.Unmarshal(data, &d), &untagged)
+	assert.EqualError(t, untagged.Candidates[0], "*ijson_test.Point: required field y not found")
+
+	err = msgpack.Unmarshal([]byte{0xc1}, &out)
+	assert.Error(t, err)
+}
+
+type Shapes struct{}
+
+var shapes = ijson.NewRegistry()
+
+func (Shapes) Registry() *ijson.Registry { return shapes }
+
+func TestUntaggedDecodable_Scope(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, ijson.RegisterUntaggedIn[Shape](shapes, func() Shape { return &Rect{} }))
+
+	var d ijson.UntaggedDecodable[Shape, Shapes]
+	require.NoError(t, json.Unmarshal([]byte(`{"w":1,"h":1}`), &d))
+	assert.Equal(t, &Rect{W: 1, H: 1}, d.I)
+
+	var untagged *ijson.UntaggedError
+	require.ErrorAs(t, json.Unmarshal([]byte(`{"x":1,"y":1}`), &d), &untagged)
+	assert.Len(t, untagged.Candidates, 1)
+}
@@ -0,0 +1,315 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xmlNode is an element read from an XML stream, kept so that it can be decoded more than once.
+// Its children are *xmlNode for child elements and character data, comments,
+// processing instructions or directives otherwise.
+type xmlNode struct {
+	start    xml.StartElement
+	children []xml.Token
+}
+
+// maxXMLDepth limits the nesting of read XML elements, like the limits of the other scanners.
+const maxXMLDepth = 10000
+
+var errXMLDepth = errors.New("ijson: xml exceeds max depth")
+
+// readXML reads the element opened by start from d, including its end element.
+func readXML(d *xml.Decoder, start xml.StartElement) (*xmlNode, error) {
+	return readXMLDepth(d, start, 1)
+}
+
+func readXMLDepth(d *xml.Decoder, start xml.StartElement, depth int) (*xmlNode, error) {
+	if depth > maxXMLDepth {
+		return nil, errXMLDepth
+	}
+
+	n := &xmlNode{start: start.Copy()}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			child, err := readXMLDepth(d, tok, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			n.children = append(n.children, child)
+		case xml.EndElement:
+			return n, nil
+		default:
+			n.children = append(n.children, xml.CopyToken(tok))
+		}
+	}
+}
+
+// tokens appends the tokens of n, from its start to its end element, to dst.
+func (n *xmlNode) tokens(dst []xml.Token) []xml.Token {
+	dst = append(dst, n.start)
+	for _, child := range n.children {
+		if c, ok := child.(*xmlNode); ok {
+			dst = c.tokens(dst)
+		} else {
+			dst = append(dst, child)
+		}
+	}
+	return append(dst, n.start.End())
+}
+
+// xmlTokens is a xml.TokenReader replaying a sequence of tokens.
+type xmlTokens []xml.Token
+
+func (t *xmlTokens) Token() (xml.Token, error) {
+	if len(*t) == 0 {
+		return nil, io.EOF
+	}
+	tok := (*t)[0]
+	*t = (*t)[1:]
+	return tok, nil
+}
+
+// decodeXML decodes the element n into v.
+func decodeXML(n *xmlNode, v any) error {
+	tokens := xmlTokens(n.tokens(nil))
+	return xml.NewTokenDecoder(&tokens).Decode(v)
+}
+
+// encodeXML encodes v as element named by start and reads the result back as node.
+// It reports false if v encodes to nothing, like a nil pointer.
+func encodeXML(v any, start xml.StartElement) (*xmlNode, bool, error) {
+	var buf bytes.Buffer
+	if err := xml.NewEncoder(&buf).EncodeElement(v, start); err != nil {
+		return nil, false, err
+	}
+
+	d := xml.NewDecoder(&buf)
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			n, err := readXML(d, start)
+			if err != nil {
+				return nil, false, err
+			}
+			n.stripNamespaces()
+			return n, true, nil
+		}
+	}
+}
+
+// stripNamespaces removes the namespace declarations of n and its children.
+// Names keep their resolved namespace, so an encoder declares them again when writing n.
+func (n *xmlNode) stripNamespaces() {
+	attrs := n.start.Attr[:0]
+	for _, a := range n.start.Attr {
+		if a.Name.Space != "xmlns" && (a.Name.Space != "" || a.Name.Local != "xmlns") {
+			attrs = append(attrs, a)
+		}
+	}
+	n.start.Attr = attrs
+
+	for _, child := range n.children {
+		if c, ok := child.(*xmlNode); ok {
+			c.stripNamespaces()
+		}
+	}
+}
+
+// xsiNamespace is the XML Schema instance namespace of xsi:type attributes.
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// writeXML writes the tokens of n to e.
+// Attributes in the XML Schema instance namespace are written with the conventional xsi prefix,
+// encoding/xml would derive an unusual prefix from the namespace otherwise.
+func writeXML(e *xml.Encoder, n *xmlNode) error {
+	for _, tok := range n.tokens(nil) {
+		if start, ok := tok.(xml.StartElement); ok {
+			tok = xsiPrefix(start)
+		}
+		if err := e.EncodeToken(tok); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xsiPrefix returns start with attributes in the XML Schema instance namespace named by the xsi prefix,
+// declaring the prefix if needed.
+func xsiPrefix(start xml.StartElement) xml.StartElement {
+	declare := false
+	attrs := make([]xml.Attr, 0, len(start.Attr)+1)
+	for _, a := range start.Attr {
+		if a.Name.Space == xsiNamespace {
+			a.Name = xml.Name{Local: "xsi:" + a.Name.Local}
+			declare = true
+		}
+		attrs = append(attrs, a)
+	}
+	if !declare {
+		return start
+	}
+
+	start.Attr = append([]xml.Attr{{Name: xml.Name{Local: "xmlns:xsi"}, Value: xsiNamespace}}, attrs...)
+	return start
+}
+
+// lookupXML returns the element at path below n. Keys match the local names of child elements,
+// and a last key starting with "@" matches the local name of an attribute,
+// which is returned as element holding the attribute value as character data.
+// If a name matches more than once, the last match is used.
+func lookupXML(n *xmlNode, path []string) (*xmlNode, bool) {
+	for i, key := range path {
+		if name, ok := strings.CutPrefix(key, "@"); ok {
+			if i != len(path)-1 {
+				return nil, false
+			}
+
+			var value *xmlNode
+			for _, a := range n.start.Attr {
+				if a.Name.Local == name {
+					value = &xmlNode{start: xml.StartElement{Name: xml.Name{Local: name}}, children: []xml.Token{xml.CharData(a.Value)}}
+				}
+			}
+			return value, value != nil
+		}
+
+		var next *xmlNode
+		for _, child := range n.children {
+			if c, ok := child.(*xmlNode); ok && c.start.Name.Local == key {
+				next = c
+			}
+		}
+		if next == nil {
+			return nil, false
+		}
+		n = next
+	}
+	return n, true
+}
+
+// nestXML returns the element named by start holding v at path,
+// as child elements or, for a last key starting with "@", as attribute.
+func nestXML(start xml.StartElement, path []string, v any) (*xmlNode, error) {
+	root := &xmlNode{start: start.Copy()}
+	n := root
+	for i, key := range path {
+		if name, ok := strings.CutPrefix(key, "@"); ok && i == len(path)-1 {
+			value, err := xmlText(v)
+			if err != nil {
+				return nil, err
+			}
+			n.start.Attr = append(n.start.Attr, xml.Attr{Name: xml.Name{Local: name}, Value: value})
+			break
+		}
+
+		if i == len(path)-1 {
+			child, ok, err := encodeXML(v, xml.StartElement{Name: xml.Name{Local: key}})
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				n.children = append(n.children, child)
+			}
+			break
+		}
+
+		child := &xmlNode{start: xml.StartElement{Name: xml.Name{Local: key}}}
+		n.children = append(n.children, child)
+		n = child
+	}
+	return root, nil
+}
+
+// xmlText returns the text of v as attribute value.
+func xmlText(v any) (string, error) {
+	switch v := v.(type) {
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		return string(text), err
+	case []byte:
+		return string(v), nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// mergeXML merges the attributes and child elements of the element tag into the element value.
+// Attributes and child elements of value with the same name as one of tag are replaced in place,
+// child elements are merged if both have children. All other attributes and child elements of tag
+// are prepended. Character data of tag is dropped.
+func mergeXML(tag, value *xmlNode) *xmlNode {
+	merged := &xmlNode{start: value.start.Copy()}
+
+	var attrs []xml.Attr
+	for _, ta := range tag.start.Attr {
+		found := false
+		for i, va := range merged.start.Attr {
+			if va.Name == ta.Name {
+				merged.start.Attr[i] = ta
+				found = true
+				break
+			}
+		}
+		if !found {
+			attrs = append(attrs, ta)
+		}
+	}
+	merged.start.Attr = append(attrs, merged.start.Attr...)
+
+	merged.children = append(merged.children, value.children...)
+	var prefix []xml.Token
+	for _, child := range tag.children {
+		tc, ok := child.(*xmlNode)
+		if !ok {
+			continue
+		}
+
+		found := false
+		for i, vchild := range merged.children {
+			if vc, ok := vchild.(*xmlNode); ok && vc.start.Name == tc.start.Name {
+				if hasXMLElements(tc) && hasXMLElements(vc) {
+					merged.children[i] = mergeXML(tc, vc)
+				} else {
+					merged.children[i] = tc
+				}
+				found = true
+				break
+			}
+		}
+		if !found {
+			prefix = append(prefix, tc)
+		}
+	}
+	merged.children = append(prefix, merged.children...)
+	return merged
+}
+
+// hasXMLElements reports whether n has child elements.
+func hasXMLElements(n *xmlNode) bool {
+	for _, child := range n.children {
+		if _, ok := child.(*xmlNode); ok {
+			return true
+		}
+	}
+	return false
+}
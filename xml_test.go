@@ -0,0 +1,186 @@
+package ijson_test
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Nikkolix/ijson"
+)
+
+type XMLShape interface {
+	Area() float64
+}
+
+type XMLCircle struct {
+	Radius float64 `xml:"radius"`
+}
+
+func (c *XMLCircle) Area() float64 { return 3 * c.Radius * c.Radius }
+
+type XMLSquare struct {
+	Side float64 `xml:"side"`
+}
+
+func (s *XMLSquare) Area() float64 { return s.Side * s.Side }
+
+// XSIType reads the discriminator from the xsi:type attribute.
+type XSIType struct {
+	Type string `xml:"http://www.w3.org/2001/XMLSchema-instance type,attr"`
+}
+
+// XMLKind reads the discriminator from the kind child element.
+type XMLKind struct {
+	Kind string `xml:"kind"`
+}
+
+type XMLVersion struct {
+	Version int `xml:"version,attr"`
+}
+
+type XSITypeField struct{}
+
+func (XSITypeField) FieldName() string { return "@type" }
+
+type XMLHeaderKind struct{}
+
+func (XMLHeaderKind) FieldName() string { return "/header/kind" }
+
+func TestDecodable_XML_Attribute(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterT[XMLCircle, XMLShape](XSIType{Type: "circle"}))
+	require.NoError(t, ijson.RegisterT[XMLSquare, XMLShape](XSIType{Type: "square"}))
+
+	type Drawing struct {
+		XMLName xml.Name                              `xml:"drawing"`
+		Shapes  []ijson.RDecodable[XMLShape, XSIType] `xml:"shape"`
+	}
+
+	var drawing Drawing
+	require.NoError(t, xml.Unmarshal([]byte(`<drawing xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <shape xsi:type="circle"><radius>2</radius></shape>
+  <shape xsi:type="square"><side>3</side></shape>
+</drawing>`), &drawing))
+	require.Len(t, drawing.Shapes, 2)
+	assert.Equal(t, &XMLCircle{Radius: 2}, drawing.Shapes[0].I)
+	assert.Equal(t, &XMLSquare{Side: 3}, drawing.Shapes[1].I)
+
+	data, err := xml.Marshal(drawing)
+	require.NoError(t, err)
+	assert.Equal(t, `<drawing><shape xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="circle"><radius>2</radius></shape><shape xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="square"><side>3</side></shape></drawing>`, string(data))
+
+	var out Drawing
+	require.NoError(t, xml.Unmarshal(data, &out))
+	assert.Equal(t, drawing, out)
+
+	data, err = xml.Marshal(Drawing{Shapes: []ijson.RDecodable[XMLShape, XSIType]{{}}})
+	require.NoError(t, err)
+	assert.Equal(t, `<drawing></drawing>`, string(data))
+}
+
+func TestDecodable_XML_ChildElement(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterT[XMLCircle, XMLShape](XMLKind{Kind: "circle"}))
+
+	var d ijson.RDecodable[XMLShape, XMLKind]
+	require.NoError(t, xml.Unmarshal([]byte(`<shape><radius>2</radius><kind>circle</kind><extra><radius>x</radius></extra></shape>`), &d))
+	assert.Equal(t, &XMLCircle{Radius: 2}, d.I)
+
+	data, err := xml.Marshal(struct {
+		XMLName xml.Name                            `xml:"doc"`
+		Shape   ijson.RDecodable[XMLShape, XMLKind] `xml:"shape"`
+	}{Shape: d})
+	require.NoError(t, err)
+	assert.Equal(t, `<doc><shape><kind>circle</kind><radius>2</radius></shape></doc>`, string(data))
+}
+
+func TestDecodable_XML_DecodableF(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterF[XMLShape, XSITypeField]("circle", func() XMLShape { return &XMLCircle{} }))
+	require.NoError(t, ijson.RegisterF[XMLShape, XMLHeaderKind]("square", func() XMLShape { return &XMLSquare{} }))
+
+	// the prefix does not matter, only the local name of the attribute
+	var f ijson.DecodableF[XMLShape, XSITypeField, string]
+	require.NoError(t, xml.Unmarshal([]byte(`<shape xmlns:x="http://www.w3.org/2001/XMLSchema-instance" x:type="circle"><radius>2</radius></shape>`), &f))
+	assert.Equal(t, &XMLCircle{Radius: 2}, f.I)
+
+	type Doc struct {
+		XMLName xml.Name                                          `xml:"doc"`
+		F       ijson.DecodableF[XMLShape, XSITypeField, string]  `xml:"f"`
+		Nested  ijson.DecodableF[XMLShape, XMLHeaderKind, string] `xml:"nested"`
+	}
+	doc := Doc{F: f, Nested: ijson.DecodableF[XMLShape, XMLHeaderKind, string]{I: &XMLSquare{Side: 3}}}
+	data, err := xml.Marshal(doc)
+	require.NoError(t, err)
+	assert.Equal(t, `<doc><f type="circle"><radius>2</radius></f><nested><header><kind>square</kind></header><side>3</side></nested></doc>`, string(data))
+
+	var out Doc
+	require.NoError(t, xml.Unmarshal(data, &out))
+	assert.Equal(t, doc.F, out.F)
+	assert.Equal(t, doc.Nested, out.Nested)
+
+	err = xml.Unmarshal([]byte(`<shape><radius>2</radius></shape>`), &f)
+	assert.ErrorIs(t, err, ijson.ErrMissingDiscriminator)
+	assert.EqualError(t, err, "xml: decide ijson_test.XMLShape for X value @type=<missing>: discriminator field @type not found")
+}
+
+type XMLKindVersion struct{}
+
+func (XMLKindVersion) FieldNames() []string { return []string{"@version", "kind"} }
+
+func TestDecodable_XML_DecodableM(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterM[XMLShape, XMLKindVersion]([2]string{"2", "circle"}, func() XMLShape { return &XMLCircle{} }))
+
+	var m ijson.DecodableM[XMLShape, XMLKindVersion, [2]string]
+	require.NoError(t, xml.Unmarshal([]byte(`<shape version="2"><kind>circle</kind><radius>2</radius></shape>`), &m))
+	assert.Equal(t, &XMLCircle{Radius: 2}, m.I)
+
+	var buf []byte
+	buf, err := xml.Marshal(struct {
+		XMLName xml.Name                                              `xml:"doc"`
+		M       ijson.DecodableM[XMLShape, XMLKindVersion, [2]string] `xml:"shape"`
+	}{M: m})
+	require.NoError(t, err)
+	assert.Equal(t, `<doc><shape version="2"><kind>circle</kind><radius>2</radius></shape></doc>`, string(buf))
+
+	err = xml.Unmarshal([]byte(`<shape><kind>circle</kind></shape>`), &m)
+	assert.ErrorIs(t, err, ijson.ErrMissingDiscriminator)
+}
+
+func TestDecodable_XML_Errors(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.RegisterT[XMLCircle, XMLShape](XMLKind{Kind: "circle"}))
+
+	tests := []struct {
+		name  string
+		data  string
+		phase ijson.Phase
+		err   string
+	}{
+		{name: "truncated", data: `<shape><kind>circle</kind>`, phase: ijson.PhaseDiscriminator, err: "xml: decode discriminator ijson_test.XMLKind: XML syntax error"},
+		{name: "unknown", data: `<shape><kind>square</kind></shape>`, phase: ijson.PhaseDecide, err: "xml: decide ijson_test.XMLShape: no factory found"},
+		{name: "invalid payload", data: `<shape><kind>circle</kind><radius>two</radius></shape>`, phase: ijson.PhasePayload, err: "xml: decode *ijson_test.XMLCircle for X value {circle}: strconv.ParseFloat"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d ijson.RDecodable[XMLShape, XMLKind]
+			err := xml.Unmarshal([]byte(tt.data), &d)
+			var decodeErr *ijson.DecodeError
+			require.ErrorAs(t, err, &decodeErr)
+			assert.Equal(t, tt.phase, decodeErr.Phase)
+			assert.Equal(t, "xml", decodeErr.Codec)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	var v ijson.RDecodable[XMLShape, XMLVersion]
+	err := xml.Unmarshal([]byte(`<shape version="two"/>`), &v)
+	var decodeErr *ijson.DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, ijson.PhaseDiscriminator, decodeErr.Phase)
+	assert.ErrorContains(t, err, "xml: decode discriminator ijson_test.XMLVersion: strconv.ParseInt")
+}
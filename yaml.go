@@ -0,0 +1,149 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// resolveYAML returns the node a document or alias node stands for.
+func resolveYAML(node *yaml.Node) *yaml.Node {
+	for node != nil {
+		switch node.Kind {
+		case yaml.DocumentNode:
+			if len(node.Content) == 0 {
+				return nil
+			}
+			node = node.Content[0]
+		case yaml.AliasNode:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+	return nil
+}
+
+// isYAMLNull reports whether node is a null scalar.
+func isYAMLNull(node *yaml.Node) bool {
+	node = resolveYAML(node)
+	return node == nil || node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null"
+}
+
+// yamlMappingError returns the error yaml reports for node that is not a mapping.
+func yamlMappingError(node *yaml.Node) error {
+	var m map[string]yaml.Node
+	return node.Decode(&m)
+}
+
+// findYAMLField returns the value of the first entry of the mapping node with a string key equal to key.
+func findYAMLField(node *yaml.Node, key string) (*yaml.Node, bool) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k := resolveYAML(node.Content[i])
+		if k != nil && k.Kind == yaml.ScalarNode && k.ShortTag() == "!!str" && k.Value == key {
+			return node.Content[i+1], true
+		}
+	}
+	return nil, false
+}
+
+// lookupYAML returns the value at path in the mapping node.
+// It reports false if a key is missing or a value on the path is not a mapping.
+// An error is returned only if node is not a mapping.
+func lookupYAML(node *yaml.Node, path []string) (*yaml.Node, bool, error) {
+	for i, key := range path {
+		node = resolveYAML(node)
+		if node == nil {
+			return nil, false, nil
+		}
+		if node.Kind != yaml.MappingNode {
+			if i == 0 {
+				return nil, false, yamlMappingError(node)
+			}
+			return nil, false, nil
+		}
+
+		var ok bool
+		node, ok = findYAMLField(node, key)
+		if !ok {
+			return nil, false, nil
+		}
+	}
+	return node, true, nil
+}
+
+// lookupYAMLPaths returns the values at paths in the mapping node, walking each mapping once.
+// The value of a path is nil if a key is missing or a value on the path is not a mapping.
+// An error is returned only if node is not a mapping.
+func lookupYAMLPaths(node *yaml.Node, paths [][]string) ([]*yaml.Node, error) {
+	values := make([]*yaml.Node, len(paths))
+	if resolveYAML(node) == nil {
+		return values, nil
+	}
+	ok := lookupPaths(node, paths, values, func(node *yaml.Node, _ []string) (func(string) (*yaml.Node, bool), bool) {
+		node = resolveYAML(node)
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil, false
+		}
+		return func(key string) (*yaml.Node, bool) { return findYAMLField(node, key) }, true
+	})
+	if !ok {
+		return nil, yamlMappingError(node)
+	}
+	return values, nil
+}
+
+// nestYAML wraps the node into mappings along path.
+func nestYAML(node *yaml.Node, path []string) *yaml.Node {
+	for i := len(path) - 1; i >= 0; i-- {
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[i]}
+		node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{key, node}}
+	}
+	return node
+}
+
+// mergeYAML merges the entries of the mapping node tag into the mapping node value.
+// Entries of value that also exist in tag are replaced in place, or merged if both are mappings,
+// all other entries of tag are prepended.
+// If either input is not a mapping, value is returned unchanged.
+func mergeYAML(tag, value *yaml.Node) *yaml.Node {
+	tag, value = resolveYAML(tag), resolveYAML(value)
+	if tag == nil || value == nil || tag.Kind != yaml.MappingNode || value.Kind != yaml.MappingNode {
+		return value
+	}
+
+	merged := make([]*yaml.Node, 0, len(tag.Content)+len(value.Content))
+	used := make([]bool, len(tag.Content)/2)
+	for i := 0; i+1 < len(value.Content); i += 2 {
+		k, v := value.Content[i], value.Content[i+1]
+		for j := 0; j+1 < len(tag.Content); j += 2 {
+			if tag.Content[j].Value == k.Value {
+				v = mergeYAMLValue(tag.Content[j+1], v)
+				used[j/2] = true
+				break
+			}
+		}
+		merged = append(merged, k, v)
+	}
+
+	prefix := make([]*yaml.Node, 0, len(tag.Content))
+	for j := 0; j+1 < len(tag.Content); j += 2 {
+		if !used[j/2] {
+			prefix = append(prefix, tag.Content[j], tag.Content[j+1])
+		}
+	}
+
+	node := *value
+	node.Content = append(prefix, merged...)
+	return &node
+}
+
+// mergeYAMLValue returns the value of a merged entry: tag, or both merged if both are mappings.
+func mergeYAMLValue(tag, value *yaml.Node) *yaml.Node {
+	if t, v := resolveYAML(tag), resolveYAML(value); t != nil && v != nil && t.Kind == yaml.MappingNode && v.Kind == yaml.MappingNode {
+		return mergeYAML(t, v)
+	}
+	return tag
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2025 Nikkolix. All rights reserved.
+// Use of this source code is governed by an MIT-style license
+// that can be found in the LICENSE file.
+
+package ijson
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	_ yaml.Marshaler   = YAMLTagDecodable[any, string, RegistryDecider[any, string]]{}
+	_ yaml.Unmarshaler = &YAMLTagDecodable[any, string, RegistryDecider[any, string]]{}
+)
+
+// YAMLTagDecodable is a generic wrapper for polymorphic (de)serialization of YAML nodes
+// whose explicit tag is the discriminator: !dog {name: Fido}.
+// The discriminator is the tag without its leading "!", so !dog is decided as "dog".
+// I is the interface type, X is the discriminator type and D is the decider.
+// Marshaling requires D to implement Discriminator.
+type YAMLTagDecodable[I any, X ~string, D Decider[I, X]] struct {
+	I I // The decoded value implementing I
+}
+
+// RYAMLTagDecodable is a type alias for YAMLTagDecodable using RegistryDecider.
+type RYAMLTagDecodable[I any, X ~string] = YAMLTagDecodable[I, X, RegistryDecider[I, X]]
+
+// MarshalYAML marshals the contained value into a YAML node tagged with its discriminator.
+func (d YAMLTagDecodable[I, X, D]) MarshalYAML() (any, error) {
+	if any(d.I) == nil {
+		return nil, nil
+	}
+
+	x, err := mustDiscriminate[I, X, D](d.I)
+	if err != nil {
+		return nil, err
+	}
+
+	var node yaml.Node
+	if err := node.Encode(d.I); err != nil {
+		return nil, err
+	}
+	node.Tag = "!" + string(x)
+	return &node, nil
+}
+
+// UnmarshalYAML decodes the YAML node into the contained value, using its explicit tag as discriminator.
+// Null decodes as nil, any other node without an explicit tag fails with an error
+// matching ErrMissingDiscriminator.
+func (d *YAMLTagDecodable[I, X, D]) UnmarshalYAML(node *yaml.Node) error {
+	if isYAMLNull(node) {
+		var i I
+		d.I = i
+		return nil
+	}
+
+	node = resolveYAML(node)
+	if node.Style&yaml.TaggedStyle == 0 {
+		return discriminatorError[I, X](codecYAML, fmt.Errorf("%w: node at line %d has no tag", ErrMissingDiscriminator, node.Line))
+	}
+
+	x := X(strings.TrimPrefix(node.Tag, "!"))
+	var decider D
+	i, err := decider.Decide(x)
+	if err != nil {
+		return decideError[I](codecYAML, x, err)
+	}
+	if any(i) == nil {
+		return payloadError(codecYAML, x, i, fmt.Errorf("cannot decode into nil %s", reflect.TypeFor[I]()))
+	}
+
+	// decode the content as if it was not tagged, so its kind resolves as usual
+	content := *node
+	content.Tag = ""
+	content.Style &^= yaml.TaggedStyle
+	if err := content.Decode(i); err != nil {
+		return payloadError(codecYAML, x, i, err)
+	}
+	d.I = i
+	return nil
+}
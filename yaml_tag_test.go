@@ -0,0 +1,109 @@
+package ijson_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Nikkolix/ijson"
+)
+
+func registerPetTags(t *testing.T) {
+	t.Helper()
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.Register[Pet]("dog", func() Pet { return &Dog{} }))
+	require.NoError(t, ijson.Register[Pet]("cat", func() Pet { return &Cat{} }))
+}
+
+func TestYAMLTagDecodable_RoundTrip(t *testing.T) {
+	registerPetTags(t)
+
+	type Owner struct {
+		Pets []ijson.RYAMLTagDecodable[Pet, string] `yaml:"pets"`
+		Best ijson.RYAMLTagDecodable[Pet, string]   `yaml:"best"`
+	}
+
+	var owner Owner
+	require.NoError(t, yaml.Unmarshal([]byte(`
+pets:
+  - !dog {name: Fido}
+  - &tom !cat
+    lives: 9
+  - *tom
+best: !dog
+  name: Rex
+`), &owner))
+	require.Len(t, owner.Pets, 3)
+	assert.Equal(t, &Dog{Name: "Fido"}, owner.Pets[0].I)
+	assert.Equal(t, &Cat{Lives: 9}, owner.Pets[1].I)
+	assert.Equal(t, &Cat{Lives: 9}, owner.Pets[2].I)
+	assert.Equal(t, &Dog{Name: "Rex"}, owner.Best.I)
+
+	data, err := yaml.Marshal(owner)
+	require.NoError(t, err)
+	assert.Equal(t, "pets:\n    - !dog\n      name: Fido\n    - !cat\n      lives: 9\n    - !cat\n      lives: 9\nbest: !dog\n    name: Rex\n", string(data))
+
+	data, err = yaml.Marshal(ijson.RYAMLTagDecodable[Pet, string]{})
+	require.NoError(t, err)
+	assert.Equal(t, "null\n", string(data))
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal(data, &node))
+	d := ijson.RYAMLTagDecodable[Pet, string]{I: &Dog{}}
+	require.NoError(t, d.UnmarshalYAML(&node))
+	assert.Nil(t, d.I)
+
+	owner.Best.I = nil
+	data, err = yaml.Marshal(owner)
+	require.NoError(t, err)
+	var again Owner
+	require.NoError(t, yaml.Unmarshal(data, &again))
+	assert.Equal(t, owner, again)
+}
+
+type PetTag string
+
+func TestYAMLTagDecodable_TypedTag(t *testing.T) {
+	ijson.ResetRegistries()
+	require.NoError(t, ijson.Register[Pet, PetTag]("dog", func() Pet { return &Dog{} }))
+
+	var d ijson.RYAMLTagDecodable[Pet, PetTag]
+	require.NoError(t, yaml.Unmarshal([]byte("!dog {name: 42}"), &d))
+	assert.Equal(t, &Dog{Name: "42"}, d.I)
+
+	data, err := yaml.Marshal(d)
+	require.NoError(t, err)
+	assert.Equal(t, "!dog\nname: \"42\"\n", string(data))
+}
+
+type Fish struct{}
+
+func (*Fish) Sound() string { return "blub" }
+
+func TestYAMLTagDecodable_Errors(t *testing.T) {
+	registerPetTags(t)
+
+	var d ijson.RYAMLTagDecodable[Pet, string]
+	err := yaml.Unmarshal([]byte("{name: Fido}"), &d)
+	assert.True(t, errors.Is(err, ijson.ErrMissingDiscriminator))
+	var decodeErr *ijson.DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, ijson.PhaseDiscriminator, decodeErr.Phase)
+	assert.Equal(t, "yaml", decodeErr.Codec)
+
+	err = yaml.Unmarshal([]byte("!bird {name: Tweety}"), &d)
+	assert.True(t, errors.Is(err, ijson.ErrNotRegistered))
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, ijson.PhaseDecide, decodeErr.Phase)
+	assert.Equal(t, "bird", decodeErr.Value)
+
+	err = yaml.Unmarshal([]byte("!dog {name: [1, 2]}"), &d)
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, ijson.PhasePayload, decodeErr.Phase)
+
+	_, err = yaml.Marshal(ijson.RYAMLTagDecodable[Pet, string]{I: &Fish{}})
+	assert.EqualError(t, err, "no discriminator found for type *ijson_test.Fish")
+}
@@ -0,0 +1,122 @@
+package ijson_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Nikkolix/ijson"
+)
+
+func TestDecodable_YAML_RoundTrip(t *testing.T) {
+	registerPets(t)
+
+	type Owner struct {
+		Pets []ijson.RDecodable[Pet, PetKind] `yaml:"pets"`
+	}
+
+	var owner Owner
+	require.NoError(t, yaml.Unmarshal([]byte(`
+pets:
+  - type: dog
+    name: rex
+  - &tom
+    lives: 9
+    type: cat
+  - *tom
+`), &owner))
+	require.Len(t, owner.Pets, 3)
+	assert.Equal(t, &Dog{Name: "rex"}, owner.Pets[0].I)
+	assert.Equal(t, &Cat{Lives: 9}, owner.Pets[1].I)
+	assert.Equal(t, &Cat{Lives: 9}, owner.Pets[2].I)
+
+	data, err := yaml.Marshal(owner)
+	require.NoError(t, err)
+	assert.Equal(t, "pets:\n    - type: dog\n      name: rex\n    - type: cat\n      lives: 9\n    - type: cat\n      lives: 9\n", string(data))
+
+	data, err = yaml.Marshal(ijson.RDecodable[Pet, PetKind]{})
+	require.NoError(t, err)
+	assert.Equal(t, "null\n", string(data))
+}
+
+func TestDecodable_YAML_Deciders(t *testing.T) {
+	var x ijson.XDecodable[I, XDeciderImpl]
+	require.NoError(t, yaml.Unmarshal([]byte("type: SB\nb: 2\n"), &x))
+	assert.Equal(t, &SB{B: 2, Type: "SB"}, x.I)
+	assert.EqualError(t, yaml.Unmarshal([]byte("type: SC\n"), &x), "yaml: decode <nil> for X value {SC}: cannot decode into nil ijson_test.I")
+
+	registerPets(t)
+	require.NoError(t, ijson.RegisterF[Pet, TestFSelector]("dog", func() Pet { return &Dog{} }))
+	require.NoError(t, ijson.RegisterF[Pet, MetadataKind]("cat", func() Pet { return &Cat{} }))
+
+	var f ijson.DecodableF[Pet, TestFSelector, string]
+	require.NoError(t, yaml.Unmarshal([]byte("{type: dog, name: rex, extra: [1, 2]}"), &f))
+	assert.Equal(t, &Dog{Name: "rex"}, f.I)
+	data, err := yaml.Marshal(f)
+	require.NoError(t, err)
+	assert.Equal(t, "type: dog\nname: rex\n", string(data))
+
+	var nested ijson.DecodableF[Pet, MetadataKind, string]
+	require.NoError(t, yaml.Unmarshal([]byte("metadata: {kind: cat}\nlives: 3\n"), &nested))
+	assert.Equal(t, &Cat{Lives: 3}, nested.I)
+	data, err = yaml.Marshal(nested)
+	require.NoError(t, err)
+	assert.Equal(t, "metadata:\n    kind: cat\nlives: 3\n", string(data))
+
+	err = yaml.Unmarshal([]byte("name: rex\n"), &f)
+	assert.ErrorIs(t, err, ijson.ErrMissingDiscriminator)
+	assert.EqualError(t, err, "yaml: decide ijson_test.Pet for X value type=<missing>: discriminator field type not found")
+}
+
+func TestDecodable_YAML_DecodableM(t *testing.T) {
+	registerObjects(t)
+
+	var o ijson.DecodableM[Object, APIKind, [2]string]
+	require.NoError(t, yaml.Unmarshal([]byte("apiVersion: apps/v1\nkind: Deployment\nmetadata: {name: web}\nreplicas: 2\n"), &o))
+	require.IsType(t, &Deployment{}, o.I)
+	assert.Equal(t, 2, o.I.(*Deployment).Replicas)
+	assert.Equal(t, "web", o.I.Name())
+
+	data, err := yaml.Marshal(ijson.DecodableM[Object, APIKind, [2]string]{I: &ConfigMap{Data: map[string]string{"a": "b"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n    name: \"\"\ndata:\n    a: b\n", string(data))
+
+	err = yaml.Unmarshal([]byte("kind: Deployment\n"), &o)
+	assert.ErrorIs(t, err, ijson.ErrMissingDiscriminator)
+}
+
+func TestDecodable_YAML_Errors(t *testing.T) {
+	registerPets(t)
+
+	tests := []struct {
+		name  string
+		data  string
+		phase ijson.Phase
+		err   string
+	}{
+		{name: "not a mapping", data: "[dog]", phase: ijson.PhaseDiscriminator, err: "cannot unmarshal !!seq into ijson_test.PetKind"},
+		{name: "unknown", data: "type: fish", phase: ijson.PhaseDecide, err: "yaml: decide ijson_test.Pet: no factory found in registry[I: ijson_test.Pet, X: ijson_test.PetKind] and X value {fish}"},
+		{name: "invalid payload", data: "type: cat\nlives: nine", phase: ijson.PhasePayload, err: "yaml: decode *ijson_test.Cat for X value {cat}: yaml: unmarshal errors:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d ijson.RDecodable[Pet, PetKind]
+			err := yaml.Unmarshal([]byte(tt.data), &d)
+			var decodeErr *ijson.DecodeError
+			require.ErrorAs(t, err, &decodeErr)
+			assert.Equal(t, tt.phase, decodeErr.Phase)
+			assert.Equal(t, "yaml", decodeErr.Codec)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	var f ijson.DecodableF[Pet, TestFSelector, string]
+	assert.ErrorContains(t, yaml.Unmarshal([]byte("[dog]"), &f), "cannot unmarshal !!seq into map[string]yaml.Node")
+	err := yaml.Unmarshal([]byte("type: {a: b}"), &f)
+	var decodeErr *ijson.DecodeError
+	require.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, ijson.PhaseDiscriminator, decodeErr.Phase)
+}